@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+type cachedResult struct {
+	result    healthcheck.Result
+	checkedAt time.Time
+}
+
+// resultCache keeps each endpoint's latest result for ttl, so serve runs a
+// check at most once per ttl however many clients ask
+type resultCache struct {
+	checker   *healthcheck.Checker
+	endpoints []healthcheck.Endpoint
+	ttl       time.Duration
+
+	// mu is held while refreshing, so concurrent requests wait for one
+	// refresh and share its results instead of each starting their own
+	mu      sync.Mutex
+	entries map[string]cachedResult
+}
+
+func newResultCache(checker *healthcheck.Checker, endpoints []healthcheck.Endpoint, ttl time.Duration) *resultCache {
+	return &resultCache{
+		checker:   checker,
+		endpoints: endpoints,
+		ttl:       ttl,
+		entries:   make(map[string]cachedResult),
+	}
+}
+
+// Results returns a result for every endpoint, checking only those with no
+// fresh entry, along with the age of the oldest result returned
+func (c *resultCache) Results(ctx context.Context) ([]healthcheck.Result, time.Duration) {
+	if c.ttl <= 0 {
+		return c.checker.Check(ctx, c.endpoints), 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var stale []healthcheck.Endpoint
+	for _, ep := range c.endpoints {
+		if entry, ok := c.entries[endpointKey(ep)]; !ok || now.Sub(entry.checkedAt) >= c.ttl {
+			stale = append(stale, ep)
+		}
+	}
+
+	if len(stale) > 0 {
+		// Other requests are waiting on this refresh, so one client hanging
+		// up mustn't cancel it; endpoint timeouts still bound it
+		for _, result := range c.checker.Check(context.WithoutCancel(ctx), stale) {
+			c.entries[resultKey(result)] = cachedResult{result: result, checkedAt: time.Now()}
+		}
+	}
+
+	results := make([]healthcheck.Result, 0, len(c.endpoints))
+	var age time.Duration
+	for _, ep := range c.endpoints {
+		entry := c.entries[endpointKey(ep)]
+		results = append(results, entry.result)
+		age = max(age, time.Since(entry.checkedAt))
+	}
+	return results, age
+}
+
+// endpointKey matches resultKey for the endpoint's results
+func endpointKey(ep healthcheck.Endpoint) string {
+	return ep.Name + "|" + ep.URL
+}
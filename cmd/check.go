@@ -1,35 +1,120 @@
 package cmd
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
-	"sync"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"cli-healthchecker/pkg/healthcheck"
 )
 
 // Flags
 var (
-	timeout int
-	urls    []string
-	verbose bool
+	timeout         secondsDuration
+	urls            []string
+	verbose         bool
+	format          string
+	configPath      string
+	retries         int
+	retryDelay      time.Duration
+	backoff         bool
+	expectCode      string
+	method          string
+	headers         []string
+	headerFile      string
+	workers         int
+	interval        time.Duration
+	maxLatency      time.Duration
+	expectBody      string
+	bodyRegex       string
+	sortBy          string
+	noColor         bool
+	fromStdin       bool
+	certWarn        int
+	insecure        bool
+	outputPath      string
+	quiet           bool
+	basicAuth       string
+	noRedirect      bool
+	proxy           string
+	wide            bool
+	slackHook       string
+	notifyHook      string
+	notifyOn        string
+	minBody         int64
+	maxBody         int64
+	expectType      string
+	repeat          int
+	demo            bool
+	dryRun          bool
+	dashboard       bool
+	urlFile         string
+	body            string
+	bodyFile        string
+	expectJSON      []string
+	expectHeaders   []string
+	failLatency     time.Duration
+	jitter          time.Duration
+	emaAlpha        float64
+	pushURL         string
+	tags            []string
+	onlyNames       []string
+	excludeNames    []string
+	maxRead         int64
+	noKeepAlive     bool
+	forceIPv4       bool
+	forceIPv6       bool
+	expectHTTP2     bool
+	exitPolicy      string
+	retryOn         string
+	manifestPath    string
+	manifestKind    string
+	jsonArray       bool
+	countCodes      bool
+	maxRedirects    int
+	userAgent       string
+	useCookies      bool
+	injectID        bool
+	idHeader        string
+	retryJitter     time.Duration
+	deadline        time.Duration
+	strictConfig    bool
+	samples         int
+	failThreshold   float64
+	minScore        float64
+	rateLimit       float64
+	outputTmpl      string
+	clientCert      string
+	clientKey       string
+	clientCA        string
+	warmup          int
+	runTimeout      time.Duration
+	progress        bool
+	templateVars    []string
+	allowUnresolved bool
+	serial          bool
 )
 
-// Endpoint represents a service to health check
-type Endpoint struct {
-	Name string
-	URL  string
-}
+// errRunTimeout is the cause of checks cut short by --run-timeout
+var errRunTimeout = errors.New("run timeout reached")
 
-// HealthResult contains detailed results from a health check
-type HealthResult struct {
-	Endpoint   Endpoint
-	IsHealthy  bool
-	StatusCode int
-	Duration   time.Duration
-	Error      error
-}
+// Values accepted by --exit-code
+var exitPolicies = []string{"any-unhealthy", "all-unhealthy", "never"}
 
 var checkCmd = &cobra.Command{
 	Use:   "check",
@@ -39,11 +124,80 @@ var checkCmd = &cobra.Command{
 	status code, response time, and overall health status.
 	
 	Examples:
-	  healthcheck check
+	  healthcheck check --demo
 	  healthcheck check --timeout 5
+	  healthcheck check --timeout 500ms
 	  healthcheck check --urls https://api.github.com,https://dog.ceo/api/breeds/list/all
-	  healthcheck check -t 3 -v`,
-	Run: runCheck,
+	  healthcheck check --demo -t 3 -v
+	  healthcheck check --format json
+	  healthcheck check --config healthcheck.yaml
+	  healthcheck check --config healthcheck.yaml --dry-run
+	  healthcheck check --config healthcheck.yaml --strict-config
+	  healthcheck check --manifest services.yaml
+	  healthcheck check --config healthcheck.yaml --tag env:prod --tag team:payments
+	  healthcheck check --config healthcheck.yaml --only "payments-*" --exclude payments-legacy
+	  healthcheck check --retries 3 --retry-delay 500ms --retry-backoff
+	  healthcheck check --retries 5 --retry-jitter 250ms --deadline 10s
+	  healthcheck check --client-cert client.pem --client-key client-key.pem --client-ca ca.pem
+	  healthcheck check --url-file urls.txt --rate 5
+	  healthcheck check --config healthcheck.yaml --serial
+	  healthcheck check --urls "https://{region}.api.example.com/health" --var region=eu
+	  healthcheck check --expect-status 200,204,301-302
+	  healthcheck check --method HEAD
+	  healthcheck check -X POST --body '{"ping":true}'
+	  healthcheck check -H "Authorization: Bearer token"
+	  healthcheck check --header-from-file headers.txt
+	  healthcheck check --concurrency 20
+	  healthcheck check --url-file urls.txt --concurrency 20 --progress
+	  healthcheck check --interval 30s
+	  healthcheck check --interval 30s --jitter 5s
+	  healthcheck check --interval 30s --ema-alpha 0.1
+	  healthcheck check --dashboard --interval 10s
+	  healthcheck check --repeat 5 --interval 10s
+	  healthcheck check --retries 3 --run-timeout 60s
+	  healthcheck check --max-latency 500ms
+	  healthcheck check --max-latency 500ms --max-latency-fail 2s
+	  healthcheck check --expect-body '"status":"ok"'
+	  healthcheck check --min-body-bytes 1 --max-body-bytes 65536
+	  healthcheck check --expect-content-type application/json
+	  healthcheck check --expect-header "X-Cache: HIT" --expect-header Strict-Transport-Security
+	  healthcheck check --expect-http2
+	  healthcheck check --max-redirects 3 --verbose
+	  healthcheck check --ipv6 --verbose --urls https://example.com
+	  healthcheck check --user-agent status-probe/1.0
+	  healthcheck check --inject-request-id --request-id-header X-Correlation-ID
+	  healthcheck check --config login.yaml --use-cookies --concurrency 1
+	  healthcheck check --expect-json status=ok --expect-json db.connected=true
+	  healthcheck check --exit-code all-unhealthy
+	  healthcheck check --config fleet.yaml --fail-threshold 20
+	  healthcheck check --config fleet.yaml --min-score 90
+	  healthcheck check --format json --json-array
+	  healthcheck check --format oneline --exit-code never
+	  healthcheck check --output-template '{{.Name}} {{.Status}} {{round .Duration}}'
+	  healthcheck check --url-file urls.txt --count-codes
+	  healthcheck check --samples 20 --urls https://api.example.com/health
+	  healthcheck check --samples 20 --warmup 2 --urls https://api.example.com/health
+	  healthcheck check --sort latency
+	  cat urls.txt | healthcheck check --stdin
+	  HEALTHCHECK_URLS=https://a.example.com,https://b.example.com healthcheck check
+	  healthcheck check --url-file urls.txt
+	  healthcheck check --urls tcp://localhost:5432
+	  healthcheck check --urls grpc://localhost:50051/my.Service
+	  healthcheck check --urls ping://example.com
+	  healthcheck check --urls dns://example.com
+	  healthcheck check --cert-warn-days 30
+	  healthcheck check -k --urls https://internal.local/health
+	  healthcheck check --format json --output results.json
+	  healthcheck check --quiet
+	  healthcheck check --basic-auth user:pass
+	  healthcheck check --no-follow-redirects --expect-status 301
+	  healthcheck check --proxy http://proxy.internal:3128
+	  healthcheck check --format table --wide
+	  healthcheck check --slack-webhook https://hooks.slack.com/services/...
+	  healthcheck check --notify-webhook https://alerts.internal/hook --notify-on change
+	  healthcheck check --history-db checks.db
+	  healthcheck check --pushgateway http://pushgateway:9091`,
+	RunE: runCheck,
 }
 
 func init() {
@@ -51,103 +205,974 @@ func init() {
 	rootCmd.AddCommand(checkCmd)
 
 	// Define flags
-	checkCmd.Flags().IntVarP(&timeout, "timeout", "t", 10, "Request timeout in seconds")
-	checkCmd.Flags().StringSliceVarP(&urls, "urls", "u", []string{}, "Comma-separated list of endpoints to check")
+	addEndpointFlags(checkCmd.Flags())
+	registerNameCompletion(checkCmd)
 	checkCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	checkCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format: "+strings.Join(formats, ", "))
+	checkCmd.Flags().DurationVar(&interval, "interval", 0, "Repeat checks at this interval until interrupted (e.g. 30s)")
+	checkCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Validate endpoints and list what would be checked without making any requests")
+	checkCmd.Flags().BoolVar(&dashboard, "dashboard", false, "Show a full-screen live view, refreshing every --interval (default 5s)")
+	checkCmd.Flags().IntVar(&repeat, "repeat", 0, "Run the checks this many times, waiting --interval between runs")
+	checkCmd.Flags().Float64Var(&emaAlpha, "ema-alpha", defaultEMAAlpha, "Smoothing factor for the moving average response time shown over repeated runs, between 0 and 1")
+	checkCmd.Flags().StringVar(&exitPolicy, "exit-code", "any-unhealthy", "When to exit 1: "+strings.Join(exitPolicies, ", "))
+	checkCmd.Flags().Float64Var(&failThreshold, "fail-threshold", 0, "Only exit 1 when at least this percentage of endpoints is unhealthy")
+	checkCmd.Flags().Float64Var(&minScore, "min-score", 0, "Exit 1 when the weighted health score is below this percentage")
+	checkCmd.Flags().StringVar(&sortBy, "sort", "name", "Sort results by name, status or latency")
+	checkCmd.Flags().BoolVar(&noColor, "no-color", false, "Disable colored output")
+	checkCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write results to this file instead of stdout (\"-\" for stdout)")
+	checkCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Only report unhealthy or degraded endpoints")
+	checkCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	checkCmd.Flags().StringVar(&outputTmpl, "output-template", "", "Go text/template to print each result with instead of the text output, e.g. '{{.Name}} {{.Status}}'")
+	checkCmd.Flags().BoolVar(&jsonArray, "json-array", false, "Print JSON output as a bare array of results, without the summary")
+	checkCmd.Flags().DurationVar(&runTimeout, "run-timeout", 0, "Most time the whole run may take; checks still going when it passes are cut short")
+	checkCmd.Flags().BoolVar(&progress, "progress", false, "Show how many checks have finished on stderr while a run is going (text output only)")
+	checkCmd.Flags().IntVar(&warmup, "warmup", 0, "Check each endpoint this many times before the measured run, without reporting the results")
+	checkCmd.Flags().IntVar(&samples, "samples", 0, "Check each endpoint this many times and report latency stats per endpoint")
+	checkCmd.Flags().BoolVar(&countCodes, "count-codes", false, "Add a count of results by status code to the summary")
+	checkCmd.Flags().BoolVar(&wide, "wide", false, "Don't truncate long values in table output")
+	checkCmd.Flags().StringVar(&slackHook, "slack-webhook", "", "Slack incoming webhook URL to alert when endpoints are unhealthy")
+	checkCmd.Flags().StringVar(&notifyHook, "notify-webhook", "", "URL to POST the results of each run to as JSON")
+	checkCmd.Flags().StringVar(&notifyOn, "notify-on", "failure", "When to POST to --notify-webhook: always, failure or change")
+	checkCmd.Flags().StringVar(&historyDB, "history-db", "", "SQLite database to append the results of each run to")
+	checkCmd.Flags().StringVar(&pushURL, "pushgateway", "", "Prometheus Pushgateway URL to push metrics to after each run")
 }
 
-func runCheck(cmd *cobra.Command, args []string) {
-	fmt.Println("Health Checker v0.1")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━")
+// addEndpointFlags registers the flags that choose endpoints and how they
+// are checked, which every command that runs checks shares
+func addEndpointFlags(flags *pflag.FlagSet) {
+	timeout = secondsDuration(healthcheck.DefaultTimeout)
+	flags.VarP(&timeout, "timeout", "t", "Request timeout, e.g. 500ms or 2m; a bare number is seconds")
+	flags.StringSliceVarP(&urls, "urls", "u", []string{}, "Comma-separated list of endpoints to check")
+	flags.StringVarP(&configPath, "config", "c", "", "Path to a YAML or JSON config file of endpoints")
+	flags.IntVar(&retries, "retries", 0, "Number of times to retry a failed check")
+	flags.DurationVar(&retryDelay, "retry-delay", time.Second, "Delay between retries")
+	flags.DurationVar(&retryJitter, "retry-jitter", 0, "Add a random delay up to this long to each retry")
+	flags.DurationVar(&deadline, "deadline", 0, "Most time to spend on one endpoint across all attempts and retries")
+	flags.StringVar(&retryOn, "retry-on", healthcheck.DefaultRetryOn, "Failures to retry: status codes or ranges, and timeout, connrefused, connection or any")
+	flags.DurationVar(&jitter, "jitter", 0, "Delay each check by a random amount up to this long to spread out requests")
+	flags.Float64Var(&rateLimit, "rate", 0, "Most requests to start per second, across all checks and retries (0 for no limit)")
+	flags.BoolVar(&backoff, "retry-backoff", false, "Double the retry delay after each attempt")
+	flags.StringVar(&expectCode, "expect-status", "", "Acceptable status codes or ranges, e.g. 200,204,301-302 (default 200-399)")
+	flags.StringVarP(&method, "method", "X", http.MethodGet, "HTTP method to use for requests")
+	flags.StringVar(&userAgent, "user-agent", "healthcheck/"+version, "User-Agent header to send with HTTP requests")
+	flags.StringArrayVarP(&headers, "header", "H", []string{}, "Request header in \"Key: Value\" format (repeatable)")
+	flags.StringVar(&headerFile, "header-from-file", "", "File of \"Key: Value\" request headers, one per line (# starts a comment)")
+	flags.IntVarP(&workers, "concurrency", "p", 10, "Maximum number of checks to run at once")
+	flags.BoolVar(&serial, "serial", false, "Check one endpoint at a time, in the order they're listed, and print results in that order")
+	flags.BoolVar(&demo, "demo", false, "Check a few sample public APIs")
+	flags.DurationVar(&maxLatency, "max-latency", 0, "Mark healthy endpoints slower than this as degraded")
+	flags.DurationVar(&failLatency, "max-latency-fail", 0, "Mark endpoints slower than this as unhealthy")
+	flags.StringVar(&expectBody, "expect-body", "", "Substring the response body must contain")
+	flags.StringVar(&body, "body", "", "Request body to send, e.g. with -X POST")
+	flags.StringVar(&bodyFile, "body-file", "", "Read the request body from this file")
+	flags.StringVar(&bodyRegex, "expect-body-regex", "", "Regular expression the response body must match")
+	flags.Int64Var(&minBody, "min-body-bytes", 0, "Minimum response body size in bytes")
+	flags.Int64Var(&maxRead, "max-read-bytes", healthcheck.DefaultMaxReadBytes, "Most bytes of a response body to read for assertions or to reuse the connection")
+	flags.Int64Var(&maxBody, "max-body-bytes", 0, "Maximum response body size in bytes")
+	flags.StringArrayVar(&expectJSON, "expect-json", nil, "JSON body assertion as path=value, e.g. status=ok or $.db.connected=true (repeatable)")
+	flags.StringArrayVar(&expectHeaders, "expect-header", nil, "Response header the endpoint must send, as \"Key: Value\" or just \"Key\" (repeatable)")
+	flags.BoolVar(&expectHTTP2, "expect-http2", false, "Mark HTTP endpoints unhealthy unless they respond over HTTP/2")
+	flags.StringVar(&expectType, "expect-content-type", "", "Media type the response Content-Type must have, e.g. application/json")
+	flags.StringArrayVar(&tags, "tag", nil, "Only check endpoints with this tag, e.g. env:prod (repeatable; endpoints must have every tag)")
+	flags.StringSliceVar(&onlyNames, "only", nil, "Only check endpoints with these names or glob patterns (case-insensitive)")
+	flags.StringSliceVar(&excludeNames, "exclude", nil, "Skip endpoints with these names or glob patterns (case-insensitive)")
+	flags.StringVar(&manifestPath, "manifest", "", "Manifest file of services to check, in the shape given by --manifest-kind")
+	flags.BoolVar(&strictConfig, "strict-config", false, "Fail if any endpoint is invalid instead of reporting it as unhealthy")
+	flags.StringVar(&manifestKind, "manifest-kind", "service", "Shape of the --manifest file: "+strings.Join(manifestKindNames(), ", "))
+	flags.StringArrayVar(&templateVars, "var", nil, "Value for {name} placeholders in endpoint URLs and names, as name=value (repeatable)")
+	flags.BoolVar(&allowUnresolved, "allow-unresolved", false, "Check endpoints with placeholders no --var fills in as written, instead of failing")
+	flags.StringVar(&urlFile, "url-file", "", "File of newline-separated URLs to check (# starts a comment)")
+	flags.BoolVar(&fromStdin, "stdin", false, "Read newline-separated URLs from standard input")
+	flags.IntVar(&certWarn, "cert-warn-days", 0, "Mark HTTPS endpoints as degraded when their certificate expires within this many days")
+	flags.BoolVarP(&insecure, "insecure", "k", false, "Skip TLS certificate verification (INSECURE: use only for trusted internal endpoints)")
+	flags.StringVar(&clientCert, "client-cert", "", "PEM client certificate to present to HTTPS endpoints, for mutual TLS")
+	flags.StringVar(&clientKey, "client-key", "", "PEM private key for --client-cert")
+	flags.StringVar(&clientCA, "client-ca", "", "PEM CA certificate to trust for HTTPS endpoints, on top of the system roots")
+	flags.StringVar(&basicAuth, "basic-auth", "", "Basic auth credentials in user:pass format")
+	flags.BoolVar(&noRedirect, "no-follow-redirects", false, "Report redirect responses instead of following them")
+	flags.IntVar(&maxRedirects, "max-redirects", healthcheck.DefaultMaxRedirects, "Most redirects to follow before failing the check")
+	flags.BoolVar(&noKeepAlive, "no-keepalive", false, "Open a new connection for every request instead of reusing them")
+	flags.BoolVar(&forceIPv4, "ipv4", false, "Only connect to and look up IPv4 addresses")
+	flags.BoolVar(&forceIPv6, "ipv6", false, "Only connect to and look up IPv6 addresses")
+	flags.BoolVar(&useCookies, "use-cookies", false, "Send cookies set by earlier responses on later requests to the same host")
+	flags.BoolVar(&injectID, "inject-request-id", false, "Send a unique request ID with every HTTP request")
+	flags.StringVar(&idHeader, "request-id-header", "X-Request-ID", "Header to send the --inject-request-id ID in")
+	flags.StringVar(&proxy, "proxy", "", "Proxy URL for all requests (default from HTTP_PROXY/HTTPS_PROXY/NO_PROXY)")
+}
 
-	if verbose {
-		fmt.Printf("⚙️ Timeout: %ds\n", timeout)
+func runCheck(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(formats, format) {
+		return fmt.Errorf("invalid format %q: must be one of %s", format, strings.Join(formats, ", "))
+	}
+	switch sortBy {
+	case "name", "status", "latency":
+	default:
+		return fmt.Errorf("invalid sort %q: must be name, status or latency", sortBy)
+	}
+	// Flags on the command line are checked by cobra, but both could come
+	// from the environment
+	if quiet && verbose {
+		return fmt.Errorf("--quiet can't be combined with --verbose")
+	}
+	if serial {
+		if flagGiven(cmd, "sort") || flagGiven(cmd, "concurrency") {
+			return fmt.Errorf("--serial can't be combined with --sort or --concurrency")
+		}
+		// Results are printed in the order the endpoints are listed
+		sortBy = ""
+	}
+	if repeat < 0 {
+		return fmt.Errorf("invalid repeat %d: must not be negative", repeat)
+	}
+	if emaAlpha <= 0 || emaAlpha > 1 {
+		return fmt.Errorf("invalid ema-alpha %v: must be greater than 0 and at most 1", emaAlpha)
+	}
+	var tmpl *template.Template
+	if outputTmpl != "" {
+		if format != "text" || dashboard || samples > 0 {
+			return fmt.Errorf("--output-template can't be combined with --format, --dashboard or --samples")
+		}
+		var err error
+		if tmpl, err = parseOutputTemplate(outputTmpl); err != nil {
+			return err
+		}
+	}
+	if dashboard && (outputPath != "" && outputPath != "-" || format != "text") {
+		return fmt.Errorf("--dashboard can't be combined with --output or --format")
+	}
+	if samples < 0 {
+		return fmt.Errorf("invalid samples %d: must not be negative", samples)
+	}
+	if warmup < 0 {
+		return fmt.Errorf("invalid warmup %d: must not be negative", warmup)
+	}
+	if runTimeout < 0 {
+		return fmt.Errorf("invalid run-timeout %v: must not be negative", runTimeout)
+	}
+	if samples > 0 && (interval > 0 || repeat > 0 || dashboard) {
+		return fmt.Errorf("--samples can't be combined with --interval, --repeat or --dashboard")
+	}
+	if samples > 0 && format != "text" && format != "json" {
+		return fmt.Errorf("--samples only supports text and json output")
+	}
+	if !slices.Contains(exitPolicies, exitPolicy) {
+		return fmt.Errorf("invalid exit-code %q: must be one of %s", exitPolicy, strings.Join(exitPolicies, ", "))
+	}
+	if failThreshold < 0 || failThreshold > 100 {
+		return fmt.Errorf("invalid fail-threshold %v: must be a percentage from 0 to 100", failThreshold)
+	}
+	if failThreshold > 0 && flagGiven(cmd, "exit-code") {
+		return fmt.Errorf("--fail-threshold can't be combined with --exit-code")
+	}
+	if minScore < 0 || minScore > 100 {
+		return fmt.Errorf("invalid min-score %v: must be a percentage from 0 to 100", minScore)
+	}
+	if minScore > 0 && (failThreshold > 0 || flagGiven(cmd, "exit-code")) {
+		return fmt.Errorf("--min-score can't be combined with --fail-threshold or --exit-code")
+	}
+	if !slices.Contains(notifyModes, notifyOn) {
+		return fmt.Errorf("invalid notify-on %q: must be one of %s", notifyOn, strings.Join(notifyModes, ", "))
+	}
+	checker, err := newChecker()
+	if err != nil {
+		return err
 	}
-	fmt.Println()
 
-	start := time.Now()
+	// --verbose turns on debug logging unless a level was chosen explicitly
+	if verbose && !flagGiven(cmd, "log-level") {
+		logLevel.Set(slog.LevelDebug)
+	}
 
-	// Use custom URLs if provided, otherwise use defaults
-	var endpoints []Endpoint
+	endpoints, err := loadEndpoints()
+	if err != nil {
+		return err
+	}
 
-	if len(urls) > 0 {
-		for i, url := range urls {
-			endpoints = append(endpoints, Endpoint{
-				Name: fmt.Sprintf("Custom-%d", i+1),
-				URL:  url,
-			})
-		}
+	out, err := openOutput(outputPath)
+	if err != nil {
+		return err
+	}
+	// Color codes only make sense on a terminal, never in a file
+	useColor = out.file == nil && shouldUseColor(noColor)
+
+	if dryRun {
+		// Invalid endpoints are listed with their errors, so usage would be noise
+		cmd.SilenceUsage = true
+		err = printDryRun(out, endpoints)
 	} else {
-		// Use default endpoints
-		endpoints = []Endpoint{
-			{Name: "Github API", URL: "https://api.github.com"},
-			{Name: "JSONPlaceholder", URL: "https://jsonplaceholder.typicode.com/posts/1"},
-			{Name: "Dog Breeds API", URL: "https://dog.ceo/api/breeds/list/all"},
+		opts := runOptions{
+			format:        format,
+			tmpl:          tmpl,
+			sortBy:        sortBy,
+			quiet:         quiet,
+			wide:          wide,
+			verbose:       verbose,
+			timeout:       time.Duration(timeout),
+			interval:      interval,
+			repeat:        repeat,
+			emaAlpha:      emaAlpha,
+			exitPolicy:    exitPolicy,
+			failThreshold: failThreshold,
+			minScore:      minScore,
+			jsonArray:     jsonArray,
+			countCodes:    countCodes,
+			slackHook:     slackHook,
+			notifyHook:    notifyHook,
+			notifyOn:      notifyOn,
+			historyDB:     historyDB,
+			pushURL:       pushURL,
+		}
+		// The line is rewritten in place, which only works next to text
+		// output, and the dashboard has the screen to itself
+		if progress && format == "text" && !dashboard {
+			opts.progress = newProgressLine(cmd.ErrOrStderr())
+			checker.OnResult = opts.progress.add
+		}
+		if runTimeout > 0 {
+			// Everything from here on, warmup included, shares the deadline
+			ctx, cancel := context.WithTimeoutCause(cmd.Context(), runTimeout, errRunTimeout)
+			defer cancel()
+			cmd.SetContext(ctx)
+		}
+		if warmup > 0 {
+			warmUp(cmd.Context(), checker, endpoints, warmup)
+		}
+		if dashboard {
+			err = runDashboard(cmd.Context(), opts, checker, endpoints)
+		} else if samples > 0 {
+			err = runSamples(cmd, out, opts, checker, endpoints, samples)
+		} else {
+			err = runChecks(cmd, out, opts, checker, endpoints)
+		}
+		// The results so far have been printed, so say why they stop there
+		if errors.Is(context.Cause(cmd.Context()), errRunTimeout) {
+			cmd.SilenceUsage = true
+			err = fmt.Errorf("run timeout of %v reached", runTimeout)
 		}
 	}
 
-	var wg sync.WaitGroup
+	// Closing flushes any buffered output, which can fail too (e.g. disk full)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
 
-	for _, endpoint := range endpoints {
-		wg.Add(1)
+// newChecker builds a Checker from the endpoint flags
+func newChecker() (*healthcheck.Checker, error) {
+	if workers < 1 {
+		return nil, fmt.Errorf("invalid concurrency %d: must be at least 1", workers)
+	}
+	if maxRedirects < 1 {
+		return nil, fmt.Errorf("invalid max-redirects %d: must be at least 1 (use --no-follow-redirects to not follow any)", maxRedirects)
+	}
+	if maxRead < 1 {
+		return nil, fmt.Errorf("invalid max-read-bytes %d: must be at least 1", maxRead)
+	}
+	concurrency := workers
+	if serial {
+		concurrency = 1
+	}
+	retryPolicy, err := healthcheck.ParseRetryPolicy(retryOn)
+	if err != nil {
+		return nil, err
+	}
+	if jitter < 0 {
+		return nil, fmt.Errorf("invalid jitter %v: must not be negative", jitter)
+	}
+	if rateLimit < 0 {
+		return nil, fmt.Errorf("invalid rate %v: must not be negative", rateLimit)
+	}
+	if retryJitter < 0 {
+		return nil, fmt.Errorf("invalid retry-jitter %v: must not be negative", retryJitter)
+	}
+	if deadline < 0 {
+		return nil, fmt.Errorf("invalid deadline %v: must not be negative", deadline)
+	}
+	var network string
+	switch {
+	case forceIPv4 && forceIPv6:
+		return nil, fmt.Errorf("--ipv4 and --ipv6 can't be used together")
+	case forceIPv4:
+		network = "tcp4"
+	case forceIPv6:
+		network = "tcp6"
+	}
+	var requestIDHeader string
+	if injectID {
+		if idHeader == "" || strings.ContainsAny(idHeader, ": \t") {
+			return nil, fmt.Errorf("invalid request-id-header %q: expected a header name", idHeader)
+		}
+		requestIDHeader = idHeader
+	}
 
-		go func(ep Endpoint) {
-			defer wg.Done()
-			result := checkEndpoint(ep)
-			printResult(result)
-		}(endpoint)
+	certs, err := loadClientCert(clientCert, clientKey)
+	if err != nil {
+		return nil, err
+	}
+	rootCAs, err := loadCAPool(clientCA)
+	if err != nil {
+		return nil, err
+	}
+
+	// --proxy wins over the HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment
+	var proxyURL *url.URL
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q: expected a URL like http://host:port", proxy)
+		}
+		proxyURL = u
+	}
+
+	return &healthcheck.Checker{
+		Retries:            retries,
+		RetryDelay:         retryDelay,
+		Backoff:            backoff,
+		RetryOn:            retryPolicy,
+		RetryJitter:        retryJitter,
+		Rate:               rateLimit,
+		Deadline:           deadline,
+		Concurrency:        concurrency,
+		Jitter:             jitter,
+		CertWarnDays:       certWarn,
+		InsecureSkipVerify: insecure,
+		ClientCertificates: certs,
+		RootCAs:            rootCAs,
+		NoRedirects:        noRedirect,
+		MaxRedirects:       maxRedirects,
+		Proxy:              proxyURL,
+		MaxReadBytes:       maxRead,
+		DisableKeepAlives:  noKeepAlive,
+		Network:            network,
+		UseCookies:         useCookies,
+		RequestIDHeader:    requestIDHeader,
+		Logger:             logger,
+	}, nil
+}
+
+// runOptions are the flags that shape a run beyond the checks themselves,
+// passed down explicitly so the run loop doesn't read flag globals
+type runOptions struct {
+	format string
+	// tmpl is the --output-template, which replaces the text output
+	tmpl    *template.Template
+	sortBy  string
+	quiet   bool
+	wide    bool
+	verbose bool
+	// timeout is only shown in the verbose banner; each endpoint has its own
+	timeout  time.Duration
+	interval time.Duration
+	repeat   int
+	emaAlpha float64
+	// exitPolicy is the --exit-code policy for failing the run, unless
+	// failThreshold or minScore is set
+	exitPolicy    string
+	failThreshold float64
+	minScore      float64
+	jsonArray     bool
+	countCodes    bool
+
+	slackHook  string
+	notifyHook string
+	notifyOn   string
+	historyDB  string
+	pushURL    string
+
+	// progress is nil unless --progress applies
+	progress *progressLine
+}
+
+// runChecks runs a single round, or repeats rounds in watch mode
+func runChecks(cmd *cobra.Command, out *outputWriter, opts runOptions, checker *healthcheck.Checker, endpoints []healthcheck.Endpoint) error {
+	// A template replaces all of the text output, banner and reports included
+	textOutput := opts.format == "text" && opts.tmpl == nil
+
+	if textOutput && !opts.quiet {
+		fmt.Fprintln(out, "Health Checker", version)
+		fmt.Fprintln(out, "━━━━━━━━━━━━━━━━━━━━━━━")
+
+		if opts.verbose {
+			fmt.Fprintf(out, "⚙️ Timeout: %v\n", opts.timeout)
+			fmt.Fprintf(out, "⚙️ Concurrency: %d\n", checker.Concurrency)
+			if opts.interval > 0 {
+				fmt.Fprintf(out, "⚙️ Interval: %v\n", opts.interval)
+			}
+		}
+		fmt.Fprintln(out)
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+
+	// Ctrl-C cancels the context, which aborts any requests still in flight
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	afterRound, closeHooks, err := newRoundHooks(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer closeHooks()
+
+	// With neither --interval nor --repeat there is just a single run
+	watch := opts.interval > 0 && opts.repeat == 0
+	runs := max(opts.repeat, 1)
+
+	var ticker *time.Ticker
+	if opts.interval > 0 {
+		ticker = time.NewTicker(opts.interval)
+		defer ticker.Stop()
+	}
+
+	var last []healthcheck.Result
+	failedRuns := 0
+	stability := newStabilityReport()
+	ema := newLatencyEMA(opts.emaAlpha)
+
+	for run := 1; watch || run <= runs; run++ {
+		if textOutput && !opts.quiet {
+			if opts.repeat > 0 {
+				fmt.Fprintf(out, "═══════ Run %d/%d · %s ═══════\n\n", run, opts.repeat, time.Now().Format(time.RFC3339))
+			} else if run > 1 {
+				fmt.Fprintf(out, "\n═══════ %s ═══════\n\n", time.Now().Format(time.RFC3339))
+			}
+		}
+
+		results, err := runRound(ctx, out, opts, checker, endpoints, ema)
+		if err != nil {
+			return err
+		}
+		afterRound(results)
+		stability.Add(results)
+
+		last = results
+		if policyFails(opts, summarize(results)) {
+			failedRuns++
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+		if !watch && run == runs {
+			break
+		}
+
+		// Wait for the next tick, or stop early if interrupted
+		if ticker != nil {
+			select {
+			case <-ctx.Done():
+			case <-ticker.C:
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if opts.repeat > 0 && textOutput && !opts.quiet {
+			fmt.Fprintln(out)
+		}
 	}
 
-	wg.Wait()
+	// The report only means something once there's more than one run, and
+	// like the summary, quiet mode only shows it when something failed
+	if textOutput && stability.Runs > 1 && (!opts.quiet || stability.Failed()) {
+		if err := printStability(out, stability); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		// Watch mode runs until it's stopped, so that's a clean exit;
+		// runCheck reports a --run-timeout itself
+		if watch {
+			return nil
+		}
+		cmd.SilenceUsage = true
+		return fmt.Errorf("health check interrupted")
+	}
 
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("✓ Health check complete", len(endpoints), time.Since(start))
+	if opts.repeat > 1 && failedRuns > 0 {
+		cmd.SilenceUsage = true
+		switch {
+		case opts.failThreshold > 0:
+			return fmt.Errorf("%d of %d runs had at least %g%% of endpoints unhealthy", failedRuns, opts.repeat, opts.failThreshold)
+		case opts.minScore > 0:
+			return fmt.Errorf("%d of %d runs had a health score below %g%%", failedRuns, opts.repeat, opts.minScore)
+		case opts.exitPolicy == "all-unhealthy":
+			return fmt.Errorf("%d of %d runs had every endpoint unhealthy", failedRuns, opts.repeat)
+		}
+		return fmt.Errorf("%d of %d runs had unhealthy endpoints", failedRuns, opts.repeat)
+	}
+	return failOnUnhealthy(cmd, last, opts)
 }
 
-func checkEndpoint(endpoint Endpoint) HealthResult {
+// newRoundHooks returns a function to call with each round's results, which
+// sends notifications, pushes metrics and records history, and a function
+// to clean up after
+func newRoundHooks(ctx context.Context, opts runOptions) (func([]healthcheck.Result), func(), error) {
+	slack := newSlackNotifier(opts.slackHook)
+	webhook := newWebhookNotifier(opts.notifyHook, opts.notifyOn)
+	gateway, err := newPushgateway(opts.pushURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The history database is only opened when asked for
+	var history *sql.DB
+	if opts.historyDB != "" {
+		history, err = openHistory(opts.historyDB)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	afterRound := func(results []healthcheck.Result) {
+		slack.notify(ctx, results)
+		webhook.notify(ctx, results)
+		gateway.push(ctx, results)
+		if history != nil {
+			if err := recordHistory(history, time.Now(), results); err != nil {
+				logger.Warn("failed to record history", "error", err)
+			}
+		}
+	}
+	closeHooks := func() {
+		if history != nil {
+			history.Close()
+		}
+	}
+	return afterRound, closeHooks, nil
+}
+
+// runRound checks every endpoint once and writes the results to out
+func runRound(ctx context.Context, out *outputWriter, opts runOptions, checker *healthcheck.Checker, endpoints []healthcheck.Endpoint, ema *latencyEMA) ([]healthcheck.Result, error) {
 	start := time.Now()
 
-	client := &http.Client{
-		Timeout: time.Duration(timeout) * time.Second,
+	opts.progress.start(len(endpoints))
+	results := checker.Check(ctx, endpoints)
+	opts.progress.finish()
+	sortResults(results, opts.sortBy)
+	ema.Add(results)
+
+	// Printing waits until every check is done so output never interleaves
+	switch opts.format {
+	case "json":
+		if err := printJSON(out, results, start, time.Since(start), opts.jsonArray, opts.countCodes); err != nil {
+			return nil, err
+		}
+	case "csv":
+		if err := printCSV(out, results); err != nil {
+			return nil, err
+		}
+	case "table":
+		if err := printTable(out, results, opts.wide); err != nil {
+			return nil, err
+		}
+	case "prometheus":
+		fmt.Fprint(out, formatPrometheus(results))
+	case "oneline":
+		if err := printOneline(out, results); err != nil {
+			return nil, err
+		}
+	default:
+		if opts.tmpl != nil {
+			if err := printTemplate(out, opts.tmpl, results, opts.quiet); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		summary := summarize(results)
+		for _, result := range results {
+			// Quiet mode only reports problems
+			if opts.quiet && result.IsHealthy && !result.Degraded {
+				continue
+			}
+			printResult(out, result, ema, opts.verbose)
+		}
+		if !opts.quiet || summary.Unhealthy > 0 {
+			printSummary(out, summary, time.Since(start), opts)
+		}
+	}
+
+	// Flush each round so watch mode output shows up as it happens
+	if err := out.Flush(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// failOnUnhealthy returns an error when the results fail the --exit-code
+// policy, --fail-threshold or --min-score, so the process exits non-zero
+// and CI pipelines fail. By default that's when anything is down.
+func failOnUnhealthy(cmd *cobra.Command, results []healthcheck.Result, opts runOptions) error {
+	summary := summarize(results)
+	if policyFails(opts, summary) {
+		// The flags were fine, so don't print usage for a failed check
+		cmd.SilenceUsage = true
+		if opts.failThreshold > 0 {
+			return fmt.Errorf("%d of %d endpoints unhealthy (%.1f%%, at or over the %g%% threshold)",
+				summary.Unhealthy, summary.Total, summary.UnhealthyPercent(), opts.failThreshold)
+		}
+		if opts.minScore > 0 {
+			return fmt.Errorf("health score %.1f%% is below the minimum of %g%% (%d of %d endpoints unhealthy)",
+				summary.Score(), opts.minScore, summary.Unhealthy, summary.Total)
+		}
+		return fmt.Errorf("%d of %d endpoints unhealthy", summary.Unhealthy, summary.Total)
+	}
+	return nil
+}
+
+// policyFails reports whether a run's results fail the --fail-threshold
+// or --min-score, when there is one, or else the --exit-code policy.
+// Cancelled and skipped checks don't count towards any of them.
+func policyFails(opts runOptions, s Summary) bool {
+	if opts.failThreshold > 0 {
+		return s.Unhealthy > 0 && s.UnhealthyPercent() >= opts.failThreshold
+	}
+	if opts.minScore > 0 {
+		return s.Score() < opts.minScore
 	}
 
-	resp, err := client.Get(endpoint.URL)
-	duration := time.Since(start)
+	switch opts.exitPolicy {
+	case "never":
+		return false
+	case "all-unhealthy":
+		return s.Unhealthy > 0 && s.Unhealthy == s.Checked()
+	default:
+		return s.Unhealthy > 0
+	}
+}
+
+// loadEndpoints resolves the endpoints to check from the flags
+func loadEndpoints() ([]healthcheck.Endpoint, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("invalid timeout %v: must be positive", time.Duration(timeout))
+	}
+
+	endpoints, err := collectEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	return prepareEndpoints(endpoints)
+}
+
+// prepareEndpoints filters endpoints by the flags, fills in the settings
+// they leave to the command line, and compiles them
+func prepareEndpoints(endpoints []healthcheck.Endpoint) ([]healthcheck.Endpoint, error) {
+	// Checked before filtering, so --only can pick an endpoint without
+	// its dependencies
+	if err := healthcheck.CheckDependencies(endpoints); err != nil {
+		return nil, fmt.Errorf("invalid depends_on: %w", err)
+	}
+	endpoints, err := filterByTags(endpoints, tags)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err = filterByName(endpoints, onlyNames, excludeNames)
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("every endpoint was excluded by --exclude")
+	}
 
+	flagHeaders, err := parseHeaders(headers)
 	if err != nil {
-		return HealthResult{
-			Endpoint:  endpoint,
-			IsHealthy: false,
-			Duration:  duration,
-			Error:     err,
+		return nil, err
+	}
+	if headerFile != "" {
+		fileHeaders, err := readHeaderFile(headerFile)
+		if err != nil {
+			return nil, err
+		}
+		// -H wins over the file for the same key
+		for k, v := range flagHeaders {
+			fileHeaders[k] = v
+		}
+		flagHeaders = fileHeaders
+	}
+
+	reqBody := body
+	if bodyFile != "" {
+		if body != "" {
+			return nil, fmt.Errorf("--body and --body-file can't be used together")
+		}
+		data, err := os.ReadFile(bodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body file: %w", err)
+		}
+		reqBody = string(data)
+	}
+
+	var authUser, authPass string
+	if basicAuth != "" {
+		var ok bool
+		authUser, authPass, ok = strings.Cut(basicAuth, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid --basic-auth: expected user:pass")
+		}
+	}
+
+	for i := range endpoints {
+		ep := &endpoints[i]
+
+		// Per-endpoint settings win over the command-line defaults
+		if ep.ExpectedStatus == "" {
+			ep.ExpectedStatus = expectCode
+		}
+		if ep.Method == "" {
+			ep.Method = method
+		}
+		if ep.UserAgent == "" {
+			ep.UserAgent = userAgent
+		}
+		ep.Method = strings.ToUpper(ep.Method)
+		if ep.MaxLatency == 0 {
+			ep.MaxLatency = healthcheck.Duration(maxLatency)
+		}
+		ep.ExpectHTTP2 = ep.ExpectHTTP2 || expectHTTP2
+		if ep.MaxLatencyFail == 0 {
+			ep.MaxLatencyFail = healthcheck.Duration(failLatency)
+		}
+		if ep.Username == "" && ep.Password == "" {
+			ep.Username, ep.Password = authUser, authPass
+		}
+		if ep.Timeout == 0 {
+			ep.Timeout = healthcheck.Duration(timeout)
+		}
+
+		if len(flagHeaders) > 0 {
+			merged := make(map[string]string, len(flagHeaders)+len(ep.Headers))
+			for k, v := range flagHeaders {
+				merged[k] = v
+			}
+			for k, v := range ep.Headers {
+				merged[k] = v
+			}
+			ep.Headers = merged
+		}
+
+		if ep.Body == "" {
+			ep.Body = reqBody
+		}
+		if ep.ExpectBody == "" {
+			ep.ExpectBody = expectBody
+		}
+		if ep.ExpectBodyRegex == "" {
+			ep.ExpectBodyRegex = bodyRegex
+		}
+		if ep.ExpectContentType == "" {
+			ep.ExpectContentType = expectType
+		}
+		if len(ep.ExpectJSON) == 0 {
+			ep.ExpectJSON = expectJSON
+		}
+		if len(ep.ExpectHeaders) == 0 {
+			ep.ExpectHeaders = expectHeaders
+		}
+		if ep.MinBodyBytes == 0 {
+			ep.MinBodyBytes = minBody
+		}
+		if ep.MaxBodyBytes == 0 {
+			ep.MaxBodyBytes = maxBody
+		}
+
+		if ep.Invalid() == nil {
+			if err := ep.Compile(); err != nil {
+				ep.SetInvalid(err)
+			}
+		}
+	}
+
+	// Report every invalid endpoint at once rather than one per run
+	var errs []error
+	for _, ep := range endpoints {
+		if err := ep.Invalid(); err != nil {
+			errs = append(errs, fmt.Errorf("endpoint %q: %w", ep.Name, err))
+		}
+	}
+	if strictConfig {
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+	} else if !dryRun {
+		// Invalid endpoints are checked as unhealthy, so the rest still run
+		for _, err := range errs {
+			logger.Warn("invalid endpoint", "error", err)
+		}
+	}
+	return endpoints, nil
+}
+
+// demoEndpoints are public sample APIs checked with --demo
+var demoEndpoints = []healthcheck.Endpoint{
+	{Name: "Github API", URL: "https://api.github.com"},
+	{Name: "JSONPlaceholder", URL: "https://jsonplaceholder.typicode.com/posts/1"},
+	{Name: "Dog Breeds API", URL: "https://dog.ceo/api/breeds/list/all"},
+}
+
+// collectEndpoints gathers endpoints from --urls or the config file, plus
+// the services in --manifest, any URLs listed in --url-file or piped in with
+// --stdin, and the samples with --demo
+func collectEndpoints() ([]healthcheck.Endpoint, error) {
+	var endpoints []healthcheck.Endpoint
+
+	// --urls takes precedence over the config file
+	if len(urls) > 0 {
+		for i, url := range urls {
+			endpoints = append(endpoints, healthcheck.Endpoint{
+				Name: fmt.Sprintf("Custom-%d", i+1),
+				URL:  url,
+			})
+		}
+	} else if configPath != "" {
+		var err error
+		endpoints, err = LoadConfig(configPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if manifestPath != "" {
+		manifestEndpoints, err := LoadManifest(manifestPath, manifestKind)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, manifestEndpoints...)
+	}
+
+	if urlFile != "" {
+		f, err := os.Open(urlFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read URL file: %w", err)
+		}
+		fileURLs, err := readURLList(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read URL file %s: %w", urlFile, err)
+		}
+		for i, url := range fileURLs {
+			endpoints = append(endpoints, healthcheck.Endpoint{
+				Name: fmt.Sprintf("File-%d", i+1),
+				URL:  url,
+			})
+		}
+	}
+
+	if fromStdin {
+		stdinURLs, err := readURLList(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read URLs from stdin: %w", err)
+		}
+		for i, url := range stdinURLs {
+			endpoints = append(endpoints, healthcheck.Endpoint{
+				Name: fmt.Sprintf("Stdin-%d", i+1),
+				URL:  url,
+			})
 		}
 	}
-	defer resp.Body.Close()
 
-	isHealthy := resp.StatusCode >= 200 && resp.StatusCode < 400
-	return HealthResult{
-		Endpoint:   endpoint,
-		IsHealthy:  isHealthy,
-		StatusCode: resp.StatusCode,
-		Duration:   duration,
-		Error:      nil,
+	// The sample APIs are opt-in so a script never hits third parties by accident
+	if demo {
+		endpoints = append(endpoints, demoEndpoints...)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoints configured: use --urls, --url-file, --config, --manifest or --stdin, or --demo to check sample APIs")
+	}
+	// Placeholders go first, since a URL with one in isn't valid
+	if err := expandEndpointVars(endpoints); err != nil {
+		return nil, err
+	}
+
+	for i := range endpoints {
+		if endpoints[i].Invalid() != nil {
+			continue
+		}
+		normalized, err := normalizeURL(endpoints[i].URL)
+		if err != nil {
+			endpoints[i].SetInvalid(err)
+			continue
+		}
+		endpoints[i].URL = normalized
 	}
+	return endpoints, nil
 }
 
-func printResult(result HealthResult) {
-	status := "✓ HEALTHY"
-	if !result.IsHealthy {
-		status = "✗ UNHEALTHY"
+func printResult(w io.Writer, result healthcheck.Result, ema *latencyEMA, verbose bool) {
+	status := colorize(colorGreen, "✓ HEALTHY")
+	if result.Cancelled() {
+		status = colorize(colorGray, "⊘ CANCELLED")
+	} else if result.Skipped {
+		status = colorize(colorGray, "↷ SKIPPED")
+	} else if !result.IsHealthy {
+		status = colorize(colorRed, "✗ UNHEALTHY")
+	} else if result.Degraded {
+		status = colorize(colorYellow, "⚠ DEGRADED")
 	}
 
-	fmt.Printf("%s [%s]\n", status, result.Endpoint.Name)
-	fmt.Printf("  URL: %s\n", result.Endpoint.URL)
+	fmt.Fprintf(w, "%s [%s]\n", status, result.Endpoint.Name)
+	fmt.Fprintf(w, "  URL: %s\n", result.Endpoint.URL)
+	if len(result.Endpoint.Tags) > 0 {
+		fmt.Fprintf(w, "  Tags: %s\n", strings.Join(result.Endpoint.Tags, ", "))
+	}
+	if verbose {
+		for _, hop := range result.Redirects {
+			fmt.Fprintf(w, "  Redirect: %d %s\n", hop.StatusCode, hop.URL)
+		}
+	}
+	if result.FinalURL != "" {
+		fmt.Fprintf(w, "  Final URL: %s\n", result.FinalURL)
+	}
 
+	// A response can still fail an assertion, so show both when we got one
+	if result.StatusCode != 0 {
+		fmt.Fprintf(w, "  Status: %d\n", result.StatusCode)
+	}
+	if result.RequestID != "" {
+		fmt.Fprintf(w, "  Request ID: %s\n", result.RequestID)
+	}
+	if result.ServingStatus != "" {
+		fmt.Fprintf(w, "  Serving Status: %s\n", result.ServingStatus)
+	}
+	if result.Responded() {
+		if avg, ok := ema.Average(result); ok {
+			fmt.Fprintf(w, "  Response Time: %v (EMA %v)\n", result.Duration, avg.Round(time.Microsecond))
+		} else {
+			fmt.Fprintf(w, "  Response Time: %v\n", result.Duration)
+		}
+	}
+	if verbose && result.RemoteAddr != "" {
+		fmt.Fprintf(w, "  Connected To: %s (%s)\n", result.RemoteAddr, result.AddressFamily())
+	}
+	if verbose && result.Proto != "" {
+		fmt.Fprintf(w, "  Protocol: %s\n", result.Proto)
+	}
+	if t := result.Timings; verbose && t.FirstByte > 0 {
+		fmt.Fprintf(w, "  Timings: DNS %v · Connect %v · TLS %v · First Byte %v\n",
+			t.DNS.Round(time.Microsecond), t.Connect.Round(time.Microsecond),
+			t.TLS.Round(time.Microsecond), t.FirstByte.Round(time.Microsecond))
+	}
+	if result.BodyRead {
+		fmt.Fprintf(w, "  Body Size: %d bytes\n", result.BodyBytes)
+	}
+	if !result.CertExpiry.IsZero() {
+		fmt.Fprintf(w, "  Certificate Expires: %s (%d days)\n", result.CertExpiry.Format("2006-01-02"), result.CertDaysLeft)
+	}
+	if result.PacketsSent > 0 {
+		fmt.Fprintf(w, "  Packet Loss: %d/%d (%.0f%%)\n", result.PacketsLost, result.PacketsSent,
+			100*float64(result.PacketsLost)/float64(result.PacketsSent))
+	}
+	if len(result.Addresses) > 0 {
+		fmt.Fprintf(w, "  Addresses: %d (%s)\n", len(result.Addresses), strings.Join(result.Addresses, ", "))
+	}
 	if result.Error != nil {
-		fmt.Printf("  Error: %v\n", result.Error)
-	} else {
-		fmt.Printf("  Status: %d\n", result.StatusCode)
-		fmt.Printf("  Response Time: %v\n", result.Duration)
+		fmt.Fprintf(w, "  Error: %v\n", result.Error)
+	}
+	if result.Attempts > 1 {
+		fmt.Fprintf(w, "  Attempts: %d\n", result.Attempts)
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 }
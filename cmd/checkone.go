@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// endpointSourceFlags choose which endpoints to check, which check-one
+// takes as its argument instead
+var endpointSourceFlags = []string{"urls", "config", "url-file", "manifest", "manifest-kind", "stdin", "demo", "tag", "only", "exclude", "strict-config"}
+
+var checkOneCmd = &cobra.Command{
+	Use:   "check-one <url>",
+	Short: "Check a single URL and exit 0 if it is healthy",
+	Long: `Checks one endpoint and prints a single line with its status, status code
+and response time, or nothing with --quiet. Exits 0 when the endpoint is
+healthy or degraded and 1 otherwise, for use in shell scripts. The same
+flags as 'check' control how it is checked, like --timeout, --expect-status
+and --header.
+
+Examples:
+  healthcheck check-one https://api.example.com/health && echo up
+  healthcheck check-one -q --expect-status 204 https://api.example.com/ready
+  healthcheck check-one --retries 3 tcp://localhost:5432`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCheckOne,
+}
+
+func init() {
+	rootCmd.AddCommand(checkOneCmd)
+
+	addEndpointFlags(checkOneCmd.Flags())
+	for _, name := range endpointSourceFlags {
+		checkOneCmd.Flags().MarkHidden(name)
+	}
+	checkOneCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Print nothing and only set the exit code")
+}
+
+func runCheckOne(cmd *cobra.Command, args []string) error {
+	if timeout <= 0 {
+		return fmt.Errorf("invalid timeout %v: must be positive", time.Duration(timeout))
+	}
+	// A URL or setting that can't be checked is a mistake in the command,
+	// not an unhealthy endpoint
+	endpoints := []healthcheck.Endpoint{{Name: args[0], URL: args[0]}}
+	if err := expandEndpointVars(endpoints); err != nil {
+		return err
+	}
+	url, err := normalizeURL(endpoints[0].URL)
+	if err != nil {
+		return err
+	}
+	endpoints[0].URL = url
+	// The hidden flags could still be set from the environment
+	tags, onlyNames, excludeNames = nil, nil, nil
+	strictConfig = true
+
+	endpoints, err = prepareEndpoints(endpoints)
+	if err != nil {
+		return err
+	}
+	checker, err := newChecker()
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+	result := checker.Check(cmd.Context(), endpoints)[0]
+	if !quiet {
+		printOneResult(cmd.OutOrStdout(), result)
+	}
+	if !result.IsHealthy {
+		return errSilentExit
+	}
+	return nil
+}
+
+// printOneResult writes a result as one line, like
+//
+//	healthy https://api.example.com/health 200 142.31ms
+//	unhealthy https://api.example.com/health 503 12.007ms
+//	unhealthy tcp://localhost:5432: dial tcp [::1]:5432: connect: connection refused
+func printOneResult(w io.Writer, result healthcheck.Result) {
+	fields := []string{resultStatus(result), result.Endpoint.URL}
+	if result.StatusCode != 0 {
+		fields = append(fields, strconv.Itoa(result.StatusCode))
+	}
+	if result.Responded() {
+		fields = append(fields, result.Duration.Round(time.Microsecond).String())
+	}
+
+	line := strings.Join(fields, " ")
+	if result.Error != nil {
+		line += ": " + result.Error.Error()
+	}
+	fmt.Fprintln(w, line)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import "os"
+
+// ANSI color codes used for status labels
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorGray   = "\033[90m"
+)
+
+// useColor is decided once per run by shouldUseColor
+var useColor bool
+
+// shouldUseColor enables color only for terminals, and never when --no-color
+// or the NO_COLOR environment variable (https://no-color.org) is set
+func shouldUseColor(disabled bool) bool {
+	if disabled {
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps text in a color when color output is enabled
+func colorize(color, text string) string {
+	if !useColor {
+		return text
+	}
+	return color + text + colorReset
+}
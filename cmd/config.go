@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// configEntry is one entry of a config file: an endpoint, plus a matrix
+// of values to expand it over
+type configEntry struct {
+	healthcheck.Endpoint `yaml:",inline"`
+
+	// Matrix maps placeholder names to their values, e.g. region: [us, eu].
+	// The entry becomes one endpoint per combination of values.
+	Matrix map[string][]string `json:"matrix" yaml:"matrix"`
+}
+
+// LoadConfig reads endpoints from a YAML or JSON config file.
+// Files ending in .json are parsed as JSON, everything else as YAML.
+//
+// An entry that can't be parsed doesn't fail the whole file: it comes back
+// marked with healthcheck.Endpoint.SetInvalid, so one typo in a large config
+// shows up as one unhealthy endpoint rather than stopping every check.
+func LoadConfig(path string) ([]healthcheck.Endpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	// Each entry is decoded on its own so a bad one can be set aside
+	var decodeEntries []func(*configEntry) error
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var cfg struct {
+			Endpoints []json.RawMessage `json:"endpoints"`
+		}
+		err = json.Unmarshal(data, &cfg)
+		for _, raw := range cfg.Endpoints {
+			decodeEntries = append(decodeEntries, func(entry *configEntry) error {
+				return json.Unmarshal(raw, entry)
+			})
+		}
+	} else {
+		var cfg struct {
+			Endpoints []yaml.Node `yaml:"endpoints"`
+		}
+		err = yaml.Unmarshal(data, &cfg)
+		for _, node := range cfg.Endpoints {
+			decodeEntries = append(decodeEntries, func(entry *configEntry) error {
+				return node.Decode(entry)
+			})
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	if len(decodeEntries) == 0 {
+		return nil, fmt.Errorf("config %s defines no endpoints", path)
+	}
+
+	var endpoints []healthcheck.Endpoint
+	for i, decode := range decodeEntries {
+		var entry configEntry
+		ep := &entry.Endpoint
+		// Whatever was decoded before an error, like the name, is kept so
+		// the entry can still be found
+		if err := decode(&entry); err != nil {
+			ep.SetInvalid(fmt.Errorf("invalid config entry: %w", err))
+		} else if ep.URL == "" {
+			ep.SetInvalid(fmt.Errorf("config entry has no url"))
+		}
+
+		// Fall back to the URL, then the position, so every endpoint has
+		// something to display
+		if ep.Name == "" {
+			ep.Name = ep.URL
+		}
+		if ep.Name == "" {
+			ep.Name = fmt.Sprintf("%s entry %d", filepath.Base(path), i+1)
+		}
+
+		if len(entry.Matrix) == 0 || ep.Invalid() != nil {
+			endpoints = append(endpoints, *ep)
+			continue
+		}
+		expanded, err := expandMatrix(*ep, entry.Matrix)
+		if err != nil {
+			ep.SetInvalid(err)
+			endpoints = append(endpoints, *ep)
+			continue
+		}
+		endpoints = append(endpoints, expanded...)
+	}
+
+	return endpoints, nil
+}
+
+// expandMatrix makes one copy of ep for every combination of the matrix
+// values, with its placeholders filled in. A name without a placeholder
+// for a dimension gets the value appended instead, so "api" over
+// region: [us, eu] becomes api-us and api-eu. Dimensions are taken in
+// alphabetical order.
+func expandMatrix(ep healthcheck.Endpoint, matrix map[string][]string) ([]healthcheck.Endpoint, error) {
+	dims := slices.Sorted(maps.Keys(matrix))
+	for _, dim := range dims {
+		if !placeholderPattern.MatchString("{" + dim + "}") {
+			return nil, fmt.Errorf("invalid matrix name %q", dim)
+		}
+		if len(matrix[dim]) == 0 {
+			return nil, fmt.Errorf("matrix %s has no values", dim)
+		}
+	}
+
+	combos := []map[string]string{{}}
+	for _, dim := range dims {
+		var next []map[string]string
+		for _, combo := range combos {
+			for _, value := range matrix[dim] {
+				c := maps.Clone(combo)
+				c[dim] = value
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+
+	endpoints := make([]healthcheck.Endpoint, 0, len(combos))
+	for _, combo := range combos {
+		e := ep
+		for _, dim := range dims {
+			if !strings.Contains(e.Name, "{"+dim+"}") {
+				e.Name += "-" + combo[dim]
+			}
+		}
+		// Placeholders the matrix doesn't fill are left for --var
+		expandEndpoint(&e, combo)
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, nil
+}
+
+// readURLList reads one URL per line, skipping blank lines and # comments
+func readURLList(r io.Reader) ([]string, error) {
+	var list []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list = append(list, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+const (
+	// defaultDashboardInterval is how often the dashboard refreshes without --interval
+	defaultDashboardInterval = 5 * time.Second
+	// sparklineLength is how many recent response times each row remembers
+	sparklineLength = 30
+	// levelSilent is above every level we log at
+	levelSilent = slog.LevelError + 4
+)
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// dashboardRow is one endpoint's latest result and recent response times.
+// Failed checks are kept as -1 so they show up as gaps in the sparkline.
+type dashboardRow struct {
+	result  healthcheck.Result
+	history []time.Duration
+}
+
+type roundMsg []healthcheck.Result
+
+type tickMsg struct{}
+
+// dashboardModel is the bubbletea model behind --dashboard
+type dashboardModel struct {
+	ctx        context.Context
+	opts       runOptions
+	checker    *healthcheck.Checker
+	endpoints  []healthcheck.Endpoint
+	afterRound func([]healthcheck.Result)
+
+	rows     map[string]*dashboardRow
+	order    []string
+	checking bool
+	lastRun  time.Time
+	width    int
+}
+
+// runDashboard shows a full-screen live view until q or Ctrl-C is pressed
+func runDashboard(ctx context.Context, opts runOptions, checker *healthcheck.Checker, endpoints []healthcheck.Endpoint) error {
+	// Quitting cancels the context, which aborts any checks still in flight
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	afterRound, closeHooks, err := newRoundHooks(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer closeHooks()
+
+	if opts.interval <= 0 {
+		opts.interval = defaultDashboardInterval
+	}
+
+	model := &dashboardModel{
+		ctx:        ctx,
+		opts:       opts,
+		checker:    checker,
+		endpoints:  endpoints,
+		afterRound: afterRound,
+		rows:       make(map[string]*dashboardRow),
+		width:      80,
+	}
+
+	// Logs written to stderr would tear up the screen, so keep them quiet
+	defer logLevel.Set(logLevel.Level())
+	logLevel.Set(levelSilent)
+
+	if _, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("dashboard failed: %w", err)
+	}
+	return nil
+}
+
+func (m *dashboardModel) Init() tea.Cmd {
+	return m.check()
+}
+
+// check runs a round in the background and reports back with a roundMsg
+func (m *dashboardModel) check() tea.Cmd {
+	m.checking = true
+	return func() tea.Msg {
+		return roundMsg(m.checker.Check(m.ctx, m.endpoints))
+	}
+}
+
+func (m *dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case roundMsg:
+		m.record(msg)
+		m.afterRound(msg)
+		return m, tea.Tick(m.opts.interval, func(time.Time) tea.Msg { return tickMsg{} })
+	case tickMsg:
+		return m, m.check()
+	}
+	return m, nil
+}
+
+// record stores a round's results, keeping rows in --sort order
+func (m *dashboardModel) record(results []healthcheck.Result) {
+	m.checking = false
+	m.lastRun = time.Now()
+	sortResults(results, m.opts.sortBy)
+
+	m.order = m.order[:0]
+	for _, result := range results {
+		key := resultKey(result)
+		row, ok := m.rows[key]
+		if !ok {
+			row = &dashboardRow{}
+			m.rows[key] = row
+		}
+		row.result = result
+
+		sample := time.Duration(-1)
+		if result.Responded() {
+			sample = result.Duration
+		}
+		row.history = append(row.history, sample)
+		if len(row.history) > sparklineLength {
+			row.history = row.history[len(row.history)-sparklineLength:]
+		}
+		m.order = append(m.order, key)
+	}
+}
+
+func (m *dashboardModel) View() string {
+	var b strings.Builder
+
+	status := "last run " + m.lastRun.Format("15:04:05")
+	if m.checking {
+		status = "checking…"
+	}
+	fmt.Fprintf(&b, "Health Checker %s · every %v · %s · q to quit\n", version, m.opts.interval, status)
+	fmt.Fprintln(&b, strings.Repeat("━", min(m.width, 80)))
+
+	nameWidth := 4
+	for _, key := range m.order {
+		nameWidth = max(nameWidth, len([]rune(m.rows[key].result.Endpoint.Name)))
+	}
+	nameWidth = min(nameWidth, maxCellWidth)
+
+	// Whatever room is left after the fixed columns goes to the detail
+	const latencyWidth = 10
+	detailWidth := m.width - (2 + nameWidth + 2 + latencyWidth + 2 + sparklineLength + 2)
+
+	var healthy, unhealthy, degraded int
+	for _, key := range m.order {
+		row := m.rows[key]
+		r := row.result
+
+		dot := colorize(colorGreen, "●")
+		switch {
+		case r.Cancelled(), r.Skipped:
+			dot = colorize(colorGray, "●")
+		case !r.IsHealthy:
+			dot = colorize(colorRed, "●")
+			unhealthy++
+		case r.Degraded:
+			dot = colorize(colorYellow, "●")
+			healthy++
+			degraded++
+		default:
+			healthy++
+		}
+
+		latency := "-"
+		if r.Responded() {
+			latency = r.Duration.Round(time.Millisecond).String()
+		}
+
+		detail := ""
+		if r.StatusCode != 0 {
+			detail = fmt.Sprintf("%d", r.StatusCode)
+		}
+		if r.Error != nil {
+			detail = strings.TrimSpace(detail + " " + r.Error.Error())
+		}
+
+		fmt.Fprintf(&b, "%s %-*s  %*s  %s  %s\n",
+			dot,
+			nameWidth, truncate(r.Endpoint.Name, nameWidth),
+			latencyWidth, latency,
+			sparkline(row.history),
+			truncate(detail, max(detailWidth, 0)))
+	}
+
+	fmt.Fprintln(&b, strings.Repeat("━", min(m.width, 80)))
+	fmt.Fprintf(&b, "Healthy: %d  Unhealthy: %d  Degraded: %d\n", healthy, unhealthy, degraded)
+	return b.String()
+}
+
+// truncate shortens s to width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// sparkline draws response times scaled between the fastest and slowest,
+// with a dot for checks that got no response
+func sparkline(samples []time.Duration) string {
+	lo, hi := time.Duration(-1), time.Duration(0)
+	for _, d := range samples {
+		if d < 0 {
+			continue
+		}
+		if lo < 0 || d < lo {
+			lo = d
+		}
+		hi = max(hi, d)
+	}
+
+	var b strings.Builder
+	for _, d := range samples {
+		switch {
+		case d < 0:
+			b.WriteString(colorize(colorRed, "·"))
+		case hi == lo:
+			b.WriteRune(sparkBars[0])
+		default:
+			i := int(float64(d-lo) / float64(hi-lo) * float64(len(sparkBars)-1))
+			b.WriteRune(sparkBars[i])
+		}
+	}
+	// Pad here since color codes would throw off fmt's width
+	b.WriteString(strings.Repeat(" ", sparklineLength-len(samples)))
+	return b.String()
+}
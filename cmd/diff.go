@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Flags
+var latencyThreshold time.Duration
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old.json> <new.json>",
+	Short: "Compare two JSON result files",
+	Long: `Compares the results of two runs saved with 'healthcheck check --format json
+--output', such as a known-good run and the current one. Lists endpoints whose
+health changed, got slower by more than --latency-threshold, or only appear
+in one of the files, and exits 1 if anything regressed.
+
+Examples:
+  healthcheck check -f json -o good.json
+  healthcheck check -f json -o now.json
+  healthcheck diff good.json now.json
+  healthcheck diff good.json now.json --latency-threshold 250ms`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().DurationVar(&latencyThreshold, "latency-threshold", 100*time.Millisecond, "Report endpoints whose response time grew by more than this")
+}
+
+// readResultsFile reads the results from a file written by --format json,
+// with or without --json-array. A file that several runs were written to
+// holds one JSON document per run, and the last one is used.
+func readResultsFile(path string) ([]jsonResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results: %w", err)
+	}
+
+	var results []jsonResult
+	found := false
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc json.RawMessage
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse results in %s: %w", path, err)
+		}
+
+		if bytes.HasPrefix(doc, []byte("[")) {
+			err = json.Unmarshal(doc, &results)
+		} else {
+			var run jsonRun
+			err = json.Unmarshal(doc, &run)
+			results = run.Results
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse results in %s: %w", path, err)
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("%s has no results", path)
+	}
+	return results, nil
+}
+
+// diffStatus ranks a result from best to worst, so a higher rank is a
+// regression. Skipped endpoints weren't checked, so rank below healthy.
+func diffStatus(r jsonResult) (int, string) {
+	switch {
+	case r.Skipped:
+		return -1, "skipped"
+	case !r.Healthy:
+		return 2, "unhealthy"
+	case r.Degraded:
+		return 1, "degraded"
+	default:
+		return 0, "healthy"
+	}
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	if latencyThreshold < 0 {
+		return fmt.Errorf("invalid latency-threshold %v: must not be negative", latencyThreshold)
+	}
+	// Past the flags, errors are about the files, not how diff was called
+	cmd.SilenceUsage = true
+
+	oldResults, err := readResultsFile(args[0])
+	if err != nil {
+		return err
+	}
+	newResults, err := readResultsFile(args[1])
+	if err != nil {
+		return err
+	}
+
+	// Endpoints are matched by name, so a changed URL is still the same endpoint
+	previous := make(map[string]jsonResult, len(oldResults))
+	for _, r := range oldResults {
+		previous[r.Name] = r
+	}
+	current := make(map[string]bool, len(newResults))
+
+	var changed, slower, added, removed []string
+	regressions := 0
+
+	for _, r := range newResults {
+		current[r.Name] = true
+		nowRank, now := diffStatus(r)
+
+		old, ok := previous[r.Name]
+		if !ok {
+			added = append(added, fmt.Sprintf("%s (%s): %s", r.Name, r.URL, now))
+			if nowRank == 2 {
+				regressions++
+			}
+			continue
+		}
+
+		wasRank, was := diffStatus(old)
+		if nowRank != wasRank {
+			line := fmt.Sprintf("%s: %s → %s", r.Name, was, now)
+			if r.Error != nil {
+				line += " (" + *r.Error + ")"
+			} else if nowRank == 2 {
+				line += fmt.Sprintf(" (status %d)", r.StatusCode)
+			}
+			changed = append(changed, line)
+			if nowRank > wasRank {
+				regressions++
+			}
+			continue
+		}
+
+		// Latency only means something when the endpoint answered both times
+		if nowRank == 2 {
+			continue
+		}
+		before := time.Duration(old.DurationMs) * time.Millisecond
+		after := time.Duration(r.DurationMs) * time.Millisecond
+		if after-before > latencyThreshold {
+			slower = append(slower, fmt.Sprintf("%s: %v → %v (+%v)", r.Name, before, after, after-before))
+			regressions++
+		}
+	}
+	for _, r := range oldResults {
+		if !current[r.Name] {
+			removed = append(removed, fmt.Sprintf("%s (%s)", r.Name, r.URL))
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Comparing %s (%d endpoints) with %s (%d endpoints)\n", args[0], len(oldResults), args[1], len(newResults))
+	printDiffSection(out, "Health changed", changed)
+	printDiffSection(out, fmt.Sprintf("Slower by more than %v", latencyThreshold), slower)
+	printDiffSection(out, "New endpoints", added)
+	printDiffSection(out, "Removed endpoints", removed)
+
+	if regressions > 0 {
+		return fmt.Errorf("%d regressions since %s", regressions, args[0])
+	}
+	if len(changed)+len(added)+len(removed) == 0 {
+		fmt.Fprintln(out, "\nNo differences")
+	}
+	return nil
+}
+
+func printDiffSection(w io.Writer, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, line := range lines {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
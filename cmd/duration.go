@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// secondsDuration is a duration flag that also takes a bare number of
+// seconds, so --timeout 10 keeps working alongside --timeout 500ms
+type secondsDuration time.Duration
+
+func (d *secondsDuration) Set(s string) error {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		*d = secondsDuration(secs * float64(time.Second))
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("expected a duration like 500ms or 2m, or a number of seconds")
+	}
+	*d = secondsDuration(parsed)
+	return nil
+}
+
+func (d *secondsDuration) String() string {
+	return time.Duration(*d).String()
+}
+
+func (d *secondsDuration) Type() string {
+	return "duration"
+}
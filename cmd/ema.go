@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"time"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// defaultEMAAlpha weights the latest response time at 30% of the average
+const defaultEMAAlpha = 0.3
+
+// latencyEMA keeps an exponential moving average of each endpoint's
+// response time across watch or repeat rounds
+type latencyEMA struct {
+	alpha   float64
+	keys    map[string]bool
+	average map[string]time.Duration
+	samples map[string]int
+}
+
+func newLatencyEMA(alpha float64) *latencyEMA {
+	e := &latencyEMA{alpha: alpha}
+	e.reset()
+	return e
+}
+
+func (e *latencyEMA) reset() {
+	e.keys = make(map[string]bool)
+	e.average = make(map[string]time.Duration)
+	e.samples = make(map[string]int)
+}
+
+// Add folds one round's response times into the averages. Checks that got
+// no response are skipped; a different set of endpoints starts over.
+func (e *latencyEMA) Add(results []healthcheck.Result) {
+	keys := make(map[string]bool, len(results))
+	for _, result := range results {
+		keys[resultKey(result)] = true
+	}
+	if !sameKeys(keys, e.keys) {
+		e.reset()
+		e.keys = keys
+	}
+
+	for _, result := range results {
+		if !result.Responded() {
+			continue
+		}
+		key := resultKey(result)
+		if e.samples[key] == 0 {
+			e.average[key] = result.Duration
+		} else {
+			e.average[key] += time.Duration(e.alpha * float64(result.Duration-e.average[key]))
+		}
+		e.samples[key]++
+	}
+}
+
+// Average returns an endpoint's moving average, once there is more than one
+// sample; a single sample would just repeat the response time
+func (e *latencyEMA) Average(result healthcheck.Result) (time.Duration, bool) {
+	key := resultKey(result)
+	return e.average[key], e.samples[key] > 1
+}
+
+func sameKeys(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key := range a {
+		if !b[key] {
+			return false
+		}
+	}
+	return true
+}
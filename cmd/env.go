@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix starts the environment variable for every flag, so --retry-delay
+// can be set with HEALTHCHECK_RETRY_DELAY
+const envPrefix = "HEALTHCHECK_"
+
+// conflictingFlags pairs the flags that can't be combined
+var conflictingFlags = [][2]string{
+	{"quiet", "verbose"},
+	{"serial", "sort"},
+	{"serial", "concurrency"},
+	{"output-template", "format"},
+	{"output-template", "dashboard"},
+	{"output-template", "samples"},
+	{"dashboard", "output"},
+	{"dashboard", "format"},
+	{"samples", "interval"},
+	{"samples", "repeat"},
+	{"samples", "dashboard"},
+	{"fail-threshold", "exit-code"},
+	{"min-score", "fail-threshold"},
+	{"min-score", "exit-code"},
+}
+
+// envFlags holds the names of the flags applyEnv set
+var envFlags = map[string]bool{}
+
+// applyEnv sets each flag not given on the command line from its environment
+// variable. The values are defaults rather than flags the user gave, so they
+// aren't marked as changed, and one that conflicts with a flag on the command
+// line is ignored. List flags, repeatable ones like --header included, take
+// comma-separated values, quoted CSV-style to hold a comma.
+func applyEnv(cmd *cobra.Command) error {
+	envFlags = map[string]bool{}
+	var err error
+	flags := cmd.Flags()
+	flags.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || f.Name == "help" || f.Name == "version" {
+			return
+		}
+		name := envName(f.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok || conflictsWithChanged(flags, f.Name) {
+			return
+		}
+		if setErr := setFromEnv(f, value); setErr != nil {
+			err = fmt.Errorf("invalid %s %q: %w", name, value, setErr)
+			return
+		}
+		envFlags[f.Name] = true
+	})
+	return err
+}
+
+// conflictsWithChanged reports whether a flag that can't be combined with
+// name was given on the command line
+func conflictsWithChanged(flags *pflag.FlagSet, name string) bool {
+	for _, pair := range conflictingFlags {
+		if pair[0] == name && flags.Changed(pair[1]) || pair[1] == name && flags.Changed(pair[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// flagGiven reports whether a flag was set on the command line or from its
+// environment variable, rather than left at its default
+func flagGiven(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Changed(name) || envFlags[name]
+}
+
+// setFromEnv sets a flag from an environment variable's value. Repeatable
+// flags would take the whole value as one item, so list values are split
+// here the way --urls splits its own.
+func setFromEnv(f *pflag.Flag, value string) error {
+	slice, ok := f.Value.(pflag.SliceValue)
+	if !ok {
+		return f.Value.Set(value)
+	}
+	items, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return err
+	}
+	return slice.Replace(items)
+}
+
+// envName maps a flag name to its environment variable
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newEnvTestCmd parses args into a command with some of check's flags and
+// applies the environment to it
+func newEnvTestCmd(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	cmd := &cobra.Command{Use: "test"}
+	flags := cmd.Flags()
+	flags.BoolP("quiet", "q", false, "")
+	flags.BoolP("verbose", "v", false, "")
+	cmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	flags.Bool("serial", false, "")
+	flags.String("sort", "name", "")
+	flags.Int("concurrency", 10, "")
+	flags.String("exit-code", "any-unhealthy", "")
+	flags.Float64("fail-threshold", 0, "")
+	flags.String("timeout", "10s", "")
+	flags.StringArray("header", nil, "")
+
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyEnv(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ValidateFlagGroups(); err != nil {
+		t.Fatalf("ValidateFlagGroups: %v", err)
+	}
+	return cmd
+}
+
+func TestApplyEnvSetsDefaults(t *testing.T) {
+	t.Setenv("HEALTHCHECK_TIMEOUT", "5s")
+	t.Setenv("HEALTHCHECK_HEADER", `X-Env: prod,"Accept: text/html, application/json"`)
+	cmd := newEnvTestCmd(t)
+
+	if got := cmd.Flags().Lookup("timeout").Value.String(); got != "5s" {
+		t.Errorf("timeout = %q, want 5s", got)
+	}
+	headers, _ := cmd.Flags().GetStringArray("header")
+	if want := []string{"X-Env: prod", "Accept: text/html, application/json"}; !slices.Equal(headers, want) {
+		t.Errorf("header = %q, want %q", headers, want)
+	}
+	// Set, but not by the user
+	if cmd.Flags().Changed("timeout") || !flagGiven(cmd, "timeout") {
+		t.Errorf("timeout: Changed = %v, flagGiven = %v; want false, true", cmd.Flags().Changed("timeout"), flagGiven(cmd, "timeout"))
+	}
+	if flagGiven(cmd, "sort") {
+		t.Error("flagGiven(sort) = true for a flag left at its default")
+	}
+}
+
+func TestApplyEnvCommandLineWins(t *testing.T) {
+	t.Setenv("HEALTHCHECK_TIMEOUT", "5s")
+	cmd := newEnvTestCmd(t, "--timeout", "2s")
+	if got := cmd.Flags().Lookup("timeout").Value.String(); got != "2s" {
+		t.Errorf("timeout = %q, want the command line's 2s", got)
+	}
+}
+
+func TestApplyEnvSkipsConflicts(t *testing.T) {
+	tests := []struct {
+		env, flag string
+		args      []string
+	}{
+		{"HEALTHCHECK_VERBOSE", "verbose", []string{"-q"}},
+		{"HEALTHCHECK_QUIET", "quiet", []string{"--verbose"}},
+		{"HEALTHCHECK_SORT", "sort", []string{"--serial"}},
+		{"HEALTHCHECK_CONCURRENCY", "concurrency", []string{"--serial"}},
+		{"HEALTHCHECK_EXIT_CODE", "exit-code", []string{"--fail-threshold", "50"}},
+	}
+	values := map[string]string{"verbose": "true", "quiet": "true", "sort": "latency", "concurrency": "3", "exit-code": "all-unhealthy"}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv(tt.env, values[tt.flag])
+			cmd := newEnvTestCmd(t, tt.args...)
+			f := cmd.Flags().Lookup(tt.flag)
+			if f.Value.String() != f.DefValue || flagGiven(cmd, tt.flag) {
+				t.Errorf("%s = %q with %v on the command line, want it ignored", tt.env, f.Value.String(), tt.args)
+			}
+		})
+	}
+}
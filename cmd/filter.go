@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// filterByTags keeps the endpoints that have every one of tags
+func filterByTags(endpoints []healthcheck.Endpoint, tags []string) ([]healthcheck.Endpoint, error) {
+	if len(tags) == 0 {
+		return endpoints, nil
+	}
+
+	var matched []healthcheck.Endpoint
+	for _, ep := range endpoints {
+		if hasTags(ep, tags) {
+			matched = append(matched, ep)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no endpoints have tag %s", strings.Join(tags, " and "))
+	}
+	return matched, nil
+}
+
+func hasTags(ep healthcheck.Endpoint, tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(ep.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterByName applies --only and --exclude, which take case-insensitive
+// names or glob patterns such as "payments-*"
+func filterByName(endpoints []healthcheck.Endpoint, only, exclude []string) ([]healthcheck.Endpoint, error) {
+	for _, pattern := range slices.Concat(only, exclude) {
+		if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %w", pattern, err)
+		}
+	}
+
+	var matched []healthcheck.Endpoint
+	for _, ep := range endpoints {
+		if len(only) > 0 && !matchesName(ep.Name, only) {
+			continue
+		}
+		if matchesName(ep.Name, exclude) {
+			continue
+		}
+		matched = append(matched, ep)
+	}
+	if len(only) > 0 && len(matched) == 0 {
+		return nil, fmt.Errorf("no endpoints match --only %s", strings.Join(only, ","))
+	}
+	return matched, nil
+}
+
+func matchesName(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		// Patterns were checked up front, so the error can be ignored
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// registerNameCompletion completes --only and --exclude with the names of
+// the endpoints in the --config file, if one was given
+// (or HEALTHCHECK_CONFIG)
+func registerNameCompletion(cmd *cobra.Command) {
+	complete := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		// Completion skips the hook that applies the environment
+		file := configPath
+		if file == "" {
+			file = os.Getenv(envName("config"))
+		}
+		if file == "" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		endpoints, err := LoadConfig(file)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		// Both flags take comma-separated lists, so only complete the last
+		// name and keep the ones before it
+		done, partial := "", toComplete
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			done, partial = toComplete[:i+1], toComplete[i+1:]
+		}
+
+		var names []string
+		for _, ep := range endpoints {
+			if strings.HasPrefix(strings.ToLower(ep.Name), strings.ToLower(partial)) {
+				names = append(names, done+ep.Name)
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	for _, flag := range []string{"only", "exclude"} {
+		// Only fails if the flag doesn't exist, which would be a bug here
+		if err := cmd.RegisterFlagCompletionFunc(flag, complete); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// parseHeader splits a curl-style "Key: Value" header
+func parseHeader(raw string) (string, string, error) {
+	key, value, ok := strings.Cut(raw, ":")
+	if !ok {
+		return "", "", fmt.Errorf("invalid header %q: expected \"Key: Value\"", raw)
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", "", fmt.Errorf("invalid header %q: missing name", raw)
+	}
+	return key, strings.TrimSpace(value), nil
+}
+
+// readHeaderFile reads "Key: Value" headers from a file, one per line.
+// Blank lines and lines starting with # are skipped.
+func readHeaderFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header file: %w", err)
+	}
+	defer f.Close()
+
+	headers := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, err := parseHeader(line)
+		if err != nil {
+			// Don't repeat the line, which may well hold a token
+			return nil, fmt.Errorf("header file %s line %d: expected \"Key: Value\"", path, n)
+		}
+		headers[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read header file %s: %w", path, err)
+	}
+	return headers, nil
+}
+
+// parseHeaders parses a list of "Key: Value" headers into a map
+func parseHeaders(raw []string) (map[string]string, error) {
+	headers := make(map[string]string, len(raw))
+	for _, h := range raw {
+		key, value, err := parseHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		headers[key] = value
+	}
+	return headers, nil
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	// Pure Go SQLite driver, so the binary stays static with CGO_ENABLED=0
+	_ "modernc.org/sqlite"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+const historySchema = `CREATE TABLE IF NOT EXISTS results (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	checked_at  TIMESTAMP NOT NULL,
+	name        TEXT NOT NULL,
+	url         TEXT NOT NULL,
+	healthy     BOOLEAN NOT NULL,
+	status_code INTEGER NOT NULL,
+	duration_ms INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_name_checked_at ON results (name, checked_at);`
+
+// Flags
+var (
+	historyDB    string
+	historyLimit int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [endpoint name]",
+	Short: "Show recent results recorded with --history-db",
+	Long: `Shows the most recent results stored by 'healthcheck check --history-db',
+	newest first, optionally for a single endpoint.
+
+	Examples:
+	  healthcheck history --history-db checks.db
+	  healthcheck history "Github API" --history-db checks.db --limit 50`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHistory,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().StringVar(&historyDB, "history-db", "", "Path to the SQLite history database")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Maximum number of results to show")
+	historyCmd.MarkFlagRequired("history-db")
+}
+
+// openHistory opens the SQLite database at path, creating the schema if needed
+func openHistory(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history db %s: %w", path, err)
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create history schema in %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// recordHistory appends one row per result, all stamped with the same time
+func recordHistory(db *sql.DB, checkedAt time.Time, results []healthcheck.Result) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO results (checked_at, name, url, healthy, status_code, duration_ms) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, result := range results {
+		// A cancelled or skipped check says nothing about the endpoint, so don't record it
+		if result.Cancelled() || result.Skipped {
+			continue
+		}
+		_, err := stmt.Exec(checkedAt.UTC(), result.Endpoint.Name, result.Endpoint.URL,
+			result.IsHealthy, result.StatusCode, result.Duration.Milliseconds())
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	// Don't create an empty database just to report that it's empty
+	if _, err := os.Stat(historyDB); err != nil {
+		return fmt.Errorf("failed to open history db: %w", err)
+	}
+
+	db, err := openHistory(historyDB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	query := `SELECT checked_at, name, url, healthy, status_code, duration_ms FROM results`
+	var params []interface{}
+	if len(args) == 1 {
+		query += ` WHERE name = ?`
+		params = append(params, args[0])
+	}
+	query += ` ORDER BY checked_at DESC, id DESC LIMIT ?`
+	params = append(params, historyLimit)
+
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECKED AT\tNAME\tURL\tHEALTHY\tCODE\tLATENCY")
+
+	for rows.Next() {
+		var (
+			checkedAt  time.Time
+			name, url  string
+			healthy    bool
+			statusCode int
+			durationMs int64
+		)
+		if err := rows.Scan(&checkedAt, &name, &url, &healthy, &statusCode, &durationMs); err != nil {
+			return fmt.Errorf("failed to read history: %w", err)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%d\t%dms\n",
+			checkedAt.Local().Format(time.RFC3339), name, url, healthy, statusCode, durationMs)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Diagnostics go to stderr so they never mix with results on stdout
+var (
+	logLevel = new(slog.LevelVar)
+	logger   = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+)
+
+// setLogLevel parses a level name (debug, info, warn or error)
+func setLogLevel(name string) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("invalid log level %q: must be debug, info, warn or error", name)
+	}
+	logLevel.Set(level)
+	return nil
+}
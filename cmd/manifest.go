@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// manifestParser turns the contents of a manifest file into endpoints
+type manifestParser func(data []byte) ([]healthcheck.Endpoint, error)
+
+// manifestKinds are the manifest shapes --manifest-kind can select. Adding
+// a shape is a matter of writing its parser and listing it here.
+var manifestKinds = map[string]manifestParser{
+	"service": parseServiceManifest,
+}
+
+// manifestKindNames lists the kinds for flag help and errors
+func manifestKindNames() []string {
+	return slices.Sorted(maps.Keys(manifestKinds))
+}
+
+// LoadManifest reads endpoints from a manifest file of the given kind
+func LoadManifest(path, kind string) ([]healthcheck.Endpoint, error) {
+	parse, ok := manifestKinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("invalid manifest kind %q: must be one of %s", kind, strings.Join(manifestKindNames(), ", "))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+	endpoints, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", path, err)
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("manifest %s defines no endpoints", path)
+	}
+	return endpoints, nil
+}
+
+// serviceManifest is one document of a "service" manifest:
+//
+//	apiVersion: v1
+//	kind: Service
+//	metadata:
+//	  name: payments
+//	  labels:
+//	    team: payments
+//	spec:
+//	  healthUrl: https://payments.internal/health
+type serviceManifest struct {
+	Metadata struct {
+		Name   string            `yaml:"name"`
+		Labels map[string]string `yaml:"labels"`
+	} `yaml:"metadata"`
+	Spec struct {
+		HealthURL      string               `yaml:"healthUrl"`
+		ExpectedStatus string               `yaml:"expectedStatus"`
+		Timeout        healthcheck.Duration `yaml:"timeout"`
+	} `yaml:"spec"`
+}
+
+// parseServiceManifest reads one service per YAML document. Documents
+// without a spec.healthUrl, such as other kinds of resource kept in the same
+// file, are skipped.
+func parseServiceManifest(data []byte) ([]healthcheck.Endpoint, error) {
+	var endpoints []healthcheck.Endpoint
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for doc := 1; ; doc++ {
+		var m serviceManifest
+		err := dec.Decode(&m)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", doc, err)
+		}
+		if m.Spec.HealthURL == "" {
+			continue
+		}
+
+		name := m.Metadata.Name
+		if name == "" {
+			name = m.Spec.HealthURL
+		}
+
+		// Labels become key:value tags, so --tag team:payments selects them
+		var tags []string
+		for key, value := range m.Metadata.Labels {
+			tags = append(tags, key+":"+value)
+		}
+		sort.Strings(tags)
+
+		endpoints = append(endpoints, healthcheck.Endpoint{
+			Name:           name,
+			URL:            m.Spec.HealthURL,
+			ExpectedStatus: m.Spec.ExpectedStatus,
+			Timeout:        m.Spec.Timeout,
+			Tags:           tags,
+		})
+	}
+	return endpoints, nil
+}
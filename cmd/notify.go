@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// notifyTimeout bounds how long a notification POST may take, so an
+// unreachable webhook can't hold up the run for long
+const notifyTimeout = 5 * time.Second
+
+// Values accepted by --notify-on
+var notifyModes = []string{"always", "failure", "change"}
+
+// slackNotifier posts failures to a Slack incoming webhook. It remembers
+// which endpoints were already failing so watch mode only alerts on
+// endpoints that have newly become unhealthy.
+type slackNotifier struct {
+	webhook string
+	failing map[string]bool
+}
+
+// newSlackNotifier returns nil when no webhook is configured
+func newSlackNotifier(webhook string) *slackNotifier {
+	if webhook == "" {
+		return nil
+	}
+	return &slackNotifier{webhook: webhook, failing: map[string]bool{}}
+}
+
+// notify sends an alert for endpoints that became unhealthy since the
+// last round. Failures to post are logged, never returned.
+func (n *slackNotifier) notify(ctx context.Context, results []healthcheck.Result) {
+	if n == nil {
+		return
+	}
+
+	var newlyFailing []healthcheck.Result
+	failing := map[string]bool{}
+	for _, result := range results {
+		if result.IsHealthy || result.Cancelled() || result.Skipped {
+			continue
+		}
+		key := resultKey(result)
+		failing[key] = true
+		if !n.failing[key] {
+			newlyFailing = append(newlyFailing, result)
+		}
+	}
+	n.failing = failing
+
+	if len(newlyFailing) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(map[string]string{"text": slackMessage(newlyFailing)})
+	if err != nil {
+		logger.Warn("failed to encode slack message", "error", err)
+		return
+	}
+	if err := postJSON(ctx, n.webhook, payload); err != nil {
+		logger.Warn("failed to send slack notification", "error", err)
+	}
+}
+
+func slackMessage(results []healthcheck.Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ":rotating_light: *healthcheck*: %d endpoint(s) unhealthy\n", len(results))
+	for _, result := range results {
+		fmt.Fprintf(&b, "• *%s* (%s): ", result.Endpoint.Name, result.Endpoint.URL)
+		if result.Error != nil {
+			fmt.Fprintf(&b, "%v\n", result.Error)
+		} else {
+			fmt.Fprintf(&b, "status %d\n", result.StatusCode)
+		}
+	}
+	return b.String()
+}
+
+// webhookNotifier POSTs the full results of a run as JSON to a URL
+type webhookNotifier struct {
+	url string
+	on  string
+	// healthy tracks each endpoint's state from the previous round for "change"
+	healthy map[string]bool
+}
+
+// webhookPayload is the JSON body sent by webhookNotifier
+type webhookPayload struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Summary   jsonSummary  `json:"summary"`
+	Results   []jsonResult `json:"results"`
+}
+
+// newWebhookNotifier returns nil when no webhook is configured
+func newWebhookNotifier(url, on string) *webhookNotifier {
+	if url == "" {
+		return nil
+	}
+	return &webhookNotifier{url: url, on: on}
+}
+
+// notify POSTs the results when the --notify-on condition is met.
+// Failures to post are logged, never returned.
+func (n *webhookNotifier) notify(ctx context.Context, results []healthcheck.Result) {
+	if n == nil {
+		return
+	}
+
+	summary := summarize(results)
+	changed := n.recordStates(results)
+
+	switch n.on {
+	case "failure":
+		if summary.Unhealthy == 0 {
+			return
+		}
+	case "change":
+		if !changed {
+			return
+		}
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		Timestamp: time.Now().UTC(),
+		Summary:   toJSONSummary(summary),
+		Results:   toJSONResults(results),
+	})
+	if err != nil {
+		logger.Warn("failed to encode webhook payload", "error", err)
+		return
+	}
+	if err := postJSON(ctx, n.url, payload); err != nil {
+		logger.Warn("failed to send webhook notification", "error", err)
+	}
+}
+
+// recordStates saves each endpoint's health and reports whether any
+// changed since the last round. The first round always counts as a change.
+func (n *webhookNotifier) recordStates(results []healthcheck.Result) bool {
+	changed := n.healthy == nil
+	healthy := make(map[string]bool, len(results))
+
+	for _, result := range results {
+		if result.Cancelled() || result.Skipped {
+			continue
+		}
+		key := resultKey(result)
+		healthy[key] = result.IsHealthy
+		if prev, ok := n.healthy[key]; !ok || prev != result.IsHealthy {
+			changed = true
+		}
+	}
+
+	n.healthy = healthy
+	return changed
+}
+
+// resultKey identifies an endpoint across rounds
+func resultKey(result healthcheck.Result) string {
+	return result.Endpoint.Name + "|" + result.Endpoint.URL
+}
+
+// postJSON POSTs payload to url with its own short timeout. It is not
+// tied to the run being cancelled, so a final alert can still go out.
+func postJSON(ctx context.Context, url string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
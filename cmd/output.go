@@ -0,0 +1,437 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// formats lists the values accepted by --format
+var formats = []string{"text", "json", "csv", "table", "prometheus", "oneline"}
+
+// maxCellWidth is where table cells are cut off unless --wide is set
+const maxCellWidth = 40
+
+// outputWriter buffers output bound for stdout or an --output file
+type outputWriter struct {
+	*bufio.Writer
+	file *os.File
+}
+
+// openOutput creates (or truncates) the file at path, or uses stdout when
+// path is empty or "-"
+func openOutput(path string) (*outputWriter, error) {
+	if path == "" || path == "-" {
+		return &outputWriter{Writer: bufio.NewWriter(os.Stdout)}, nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	return &outputWriter{Writer: bufio.NewWriter(f), file: f}, nil
+}
+
+// Flush writes any buffered output
+func (o *outputWriter) Flush() error {
+	if err := o.Writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// Close flushes buffered output and closes the file, if there is one
+func (o *outputWriter) Close() error {
+	if err := o.Flush(); err != nil {
+		return err
+	}
+	if o.file != nil {
+		if err := o.file.Close(); err != nil {
+			return fmt.Errorf("failed to close output file: %w", err)
+		}
+	}
+	return nil
+}
+
+// sortResults orders results so output is the same from run to run.
+// "status" puts unhealthy endpoints first, then degraded, then healthy.
+// Ties are broken by name. An empty by leaves them in the order checked.
+func sortResults(results []healthcheck.Result, by string) {
+	if by == "" {
+		return
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch by {
+		case "status":
+			if statusRank(a) != statusRank(b) {
+				return statusRank(a) < statusRank(b)
+			}
+		case "latency":
+			if a.Duration != b.Duration {
+				return a.Duration < b.Duration
+			}
+		}
+		if a.Endpoint.Name != b.Endpoint.Name {
+			return a.Endpoint.Name < b.Endpoint.Name
+		}
+		return a.Endpoint.URL < b.Endpoint.URL
+	})
+}
+
+func statusRank(result healthcheck.Result) int {
+	switch {
+	case result.Skipped:
+		return 3
+	case !result.IsHealthy:
+		return 0
+	case result.Degraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// jsonResult is the JSON representation of a healthcheck.Result
+type jsonResult struct {
+	Name          string         `json:"name"`
+	URL           string         `json:"url"`
+	Tags          []string       `json:"tags,omitempty"`
+	FinalURL      string         `json:"final_url,omitempty"`
+	Redirects     []jsonRedirect `json:"redirects,omitempty"`
+	Healthy       bool           `json:"healthy"`
+	Degraded      bool           `json:"degraded"`
+	Skipped       bool           `json:"skipped,omitempty"`
+	StatusCode    int            `json:"status_code"`
+	Protocol      string         `json:"protocol,omitempty"`
+	RemoteAddr    string         `json:"remote_addr,omitempty"`
+	AddressFamily string         `json:"address_family,omitempty"`
+	RequestID     string         `json:"request_id,omitempty"`
+	DurationMs    int64          `json:"duration_ms"`
+	Error         *string        `json:"error"`
+	ErrorKind     string         `json:"error_kind,omitempty"`
+	Attempts      int            `json:"attempts"`
+	ServingStatus string         `json:"serving_status,omitempty"`
+	PacketsSent   *int           `json:"packets_sent,omitempty"`
+	PacketsLost   *int           `json:"packets_lost,omitempty"`
+	BodyBytes     *int64         `json:"body_bytes,omitempty"`
+	Addresses     []string       `json:"addresses,omitempty"`
+	CertExpiry    *time.Time     `json:"cert_expiry,omitempty"`
+	CertDaysLeft  *int           `json:"cert_days_left,omitempty"`
+	Timings       *jsonTimings   `json:"timings,omitempty"`
+}
+
+type jsonRedirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+// jsonTimings is the JSON representation of healthcheck.Timings, in
+// fractional milliseconds since the phases are often well under one
+type jsonTimings struct {
+	DNSMs       float64 `json:"dns_ms"`
+	ConnectMs   float64 `json:"connect_ms"`
+	TLSMs       float64 `json:"tls_ms"`
+	FirstByteMs float64 `json:"first_byte_ms"`
+}
+
+func toJSONTimings(t healthcheck.Timings) *jsonTimings {
+	ms := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+	return &jsonTimings{
+		DNSMs:       ms(t.DNS),
+		ConnectMs:   ms(t.Connect),
+		TLSMs:       ms(t.TLS),
+		FirstByteMs: ms(t.FirstByte),
+	}
+}
+
+func toJSONResult(result healthcheck.Result) jsonResult {
+	jr := jsonResult{
+		Name:          result.Endpoint.Name,
+		URL:           result.Endpoint.URL,
+		Tags:          result.Endpoint.Tags,
+		FinalURL:      result.FinalURL,
+		Healthy:       result.IsHealthy,
+		Degraded:      result.Degraded,
+		Skipped:       result.Skipped,
+		StatusCode:    result.StatusCode,
+		Protocol:      result.Proto,
+		RemoteAddr:    result.RemoteAddr,
+		AddressFamily: result.AddressFamily(),
+		RequestID:     result.RequestID,
+		DurationMs:    result.Duration.Milliseconds(),
+		ErrorKind:     string(result.ErrorKind),
+		Attempts:      result.Attempts,
+		Addresses:     result.Addresses,
+		ServingStatus: result.ServingStatus,
+	}
+
+	for _, hop := range result.Redirects {
+		jr.Redirects = append(jr.Redirects, jsonRedirect{URL: hop.URL, StatusCode: hop.StatusCode})
+	}
+	if result.BodyRead {
+		jr.BodyBytes = &result.BodyBytes
+	}
+	// Only HTTP checks that got a response have the full breakdown
+	if result.Timings.FirstByte > 0 {
+		jr.Timings = toJSONTimings(result.Timings)
+	}
+	if result.PacketsSent > 0 {
+		jr.PacketsSent = &result.PacketsSent
+		jr.PacketsLost = &result.PacketsLost
+	}
+
+	// Non-TLS endpoints have no certificate, so leave the fields out
+	if !result.CertExpiry.IsZero() {
+		jr.CertExpiry = &result.CertExpiry
+		jr.CertDaysLeft = &result.CertDaysLeft
+	}
+
+	// Errors don't marshal to anything useful, so keep just the message
+	if result.Error != nil {
+		msg := result.Error.Error()
+		jr.Error = &msg
+	}
+	return jr
+}
+
+func toJSONResults(results []healthcheck.Result) []jsonResult {
+	out := make([]jsonResult, 0, len(results))
+	for _, result := range results {
+		out = append(out, toJSONResult(result))
+	}
+	return out
+}
+
+// jsonSummary is the JSON representation of a Summary
+type jsonSummary struct {
+	Total     int `json:"total"`
+	Healthy   int `json:"healthy"`
+	Unhealthy int `json:"unhealthy"`
+	Degraded  int `json:"degraded"`
+	Cancelled int `json:"cancelled"`
+	Skipped   int `json:"skipped"`
+}
+
+func toJSONSummary(s Summary) jsonSummary {
+	return jsonSummary{
+		Total:     s.Total,
+		Healthy:   s.Healthy,
+		Unhealthy: s.Unhealthy,
+		Degraded:  s.Degraded,
+		Cancelled: s.Cancelled,
+		Skipped:   s.Skipped,
+	}
+}
+
+// jsonRun is the JSON output of a run: its results and what they add up to
+type jsonRun struct {
+	Summary jsonRunSummary `json:"summary"`
+	Results []jsonResult   `json:"results"`
+}
+
+type jsonRunSummary struct {
+	jsonSummary
+	StartedAt       time.Time `json:"started_at"`
+	TotalDurationMs int64     `json:"total_duration_ms"`
+	// UnhealthyPercent leaves out cancelled checks, as --fail-threshold does
+	UnhealthyPercent float64 `json:"unhealthy_percent"`
+	// Score is the weighted health score, as --min-score uses it
+	Score float64 `json:"score"`
+	// StatusCodes is only filled in with --count-codes
+	StatusCodes map[string]int `json:"status_codes,omitempty"`
+}
+
+// printJSON writes a run as a jsonRun, or with bareArray as just the
+// array of results, which is how it was printed before the summary
+func printJSON(w io.Writer, results []healthcheck.Result, started time.Time, elapsed time.Duration, bareArray, countCodes bool) error {
+	var v interface{} = toJSONResults(results)
+	if !bareArray {
+		summary := summarize(results)
+		run := jsonRun{
+			Summary: jsonRunSummary{
+				jsonSummary:      toJSONSummary(summary),
+				StartedAt:        started.UTC(),
+				TotalDurationMs:  elapsed.Milliseconds(),
+				UnhealthyPercent: math.Round(summary.UnhealthyPercent()*10) / 10,
+				Score:            math.Round(summary.Score()*10) / 10,
+			},
+			Results: toJSONResults(results),
+		}
+		if countCodes {
+			run.Summary.StatusCodes = make(map[string]int, len(summary.StatusCodes))
+			for code, n := range summary.StatusCodes {
+				run.Summary.StatusCodes[statusCodeLabel(code)] = n
+			}
+		}
+		v = run
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
+
+// printCSV writes a header row and one row per result. encoding/csv takes
+// care of quoting error messages that contain commas or quotes.
+func printCSV(w io.Writer, results []healthcheck.Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"name", "url", "healthy", "status_code", "duration_ms", "error"}); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		errMsg := ""
+		if result.Error != nil {
+			errMsg = result.Error.Error()
+		}
+
+		row := []string{
+			result.Endpoint.Name,
+			result.Endpoint.URL,
+			strconv.FormatBool(result.IsHealthy),
+			strconv.Itoa(result.StatusCode),
+			strconv.FormatInt(result.Duration.Milliseconds(), 10),
+			errMsg,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// printOneline writes a single line for status bars, like
+//
+//	healthy 8/10 (2 down: api, cache)
+//
+// Groups that are empty are left out, so everything healthy is just
+// "healthy 10/10".
+func printOneline(w io.Writer, results []healthcheck.Result) error {
+	groups := map[string][]string{}
+	healthy := 0
+	for _, result := range results {
+		status := resultStatus(result)
+		if status == "healthy" || status == "degraded" {
+			healthy++
+		}
+		groups[status] = append(groups[status], result.Endpoint.Name)
+	}
+
+	line := fmt.Sprintf("healthy %d/%d", healthy, len(results))
+	var parts []string
+	for _, g := range []struct{ status, label string }{
+		{"unhealthy", "down"},
+		{"degraded", "degraded"},
+		{"skipped", "skipped"},
+		{"cancelled", "cancelled"},
+	} {
+		if names := groups[g.status]; len(names) > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s: %s", len(names), g.label, strings.Join(names, ", ")))
+		}
+	}
+	if len(parts) > 0 {
+		line += " (" + strings.Join(parts, "; ") + ")"
+	}
+
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// printTable writes an aligned table with one row per result
+func printTable(w io.Writer, results []healthcheck.Result, wide bool) error {
+	cell := func(s string) string {
+		if wide || len([]rune(s)) <= maxCellWidth {
+			return s
+		}
+		return string([]rune(s)[:maxCellWidth-1]) + "…"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tURL\tSTATUS\tCODE\tLATENCY\tERROR")
+
+	for _, result := range results {
+		status := resultStatus(result)
+
+		code := "-"
+		if result.StatusCode != 0 {
+			code = strconv.Itoa(result.StatusCode)
+		}
+		latency := "-"
+		if result.Responded() {
+			latency = result.Duration.Round(time.Microsecond).String()
+		}
+		errMsg := ""
+		if result.Error != nil {
+			errMsg = result.Error.Error()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			cell(result.Endpoint.Name), cell(result.Endpoint.URL), status, code, latency, cell(errMsg))
+	}
+
+	return tw.Flush()
+}
+
+// formatPrometheus renders results in the Prometheus text exposition format
+func formatPrometheus(results []healthcheck.Result) string {
+	var b strings.Builder
+
+	b.WriteString("# HELP healthcheck_up Whether the endpoint is healthy (1) or not (0).\n")
+	b.WriteString("# TYPE healthcheck_up gauge\n")
+	for _, result := range results {
+		up := 0
+		if result.IsHealthy {
+			up = 1
+		}
+		fmt.Fprintf(&b, "healthcheck_up%s %d\n", promLabels(result), up)
+	}
+
+	b.WriteString("# HELP healthcheck_response_seconds Response time of the last check in seconds.\n")
+	b.WriteString("# TYPE healthcheck_response_seconds gauge\n")
+	for _, result := range results {
+		fmt.Fprintf(&b, "healthcheck_response_seconds%s %g\n", promLabels(result), result.Duration.Seconds())
+	}
+
+	b.WriteString("# HELP healthcheck_status_code HTTP status code of the last check, 0 if there was no response.\n")
+	b.WriteString("# TYPE healthcheck_status_code gauge\n")
+	for _, result := range results {
+		fmt.Fprintf(&b, "healthcheck_status_code%s %d\n", promLabels(result), result.StatusCode)
+	}
+
+	return b.String()
+}
+
+// promLabels labels a metric with the endpoint's name, URL and tags. The
+// tags are joined into one label, since Prometheus expects every series of a
+// metric to have the same label names.
+func promLabels(result healthcheck.Result) string {
+	return fmt.Sprintf(`{name="%s",url="%s",tags="%s"}`,
+		promEscape(result.Endpoint.Name), promEscape(result.Endpoint.URL),
+		promEscape(strings.Join(result.Endpoint.Tags, ",")))
+}
+
+// promLabelEscaper escapes label values as the exposition format requires
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func promEscape(value string) string {
+	return promLabelEscaper.Replace(value)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// progressLine shows how far a round has got on a single line of stderr,
+// rewritten with a carriage return as each result comes in. A nil
+// progressLine shows nothing.
+type progressLine struct {
+	w io.Writer
+
+	mu    sync.Mutex
+	total int
+	done  int
+	// width is the length of the last line written, so clear can blank it
+	width int
+}
+
+func newProgressLine(w io.Writer) *progressLine {
+	return &progressLine{w: w}
+}
+
+// start begins counting a round of total checks
+func (p *progressLine) start(total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total, p.done = total, 0
+	p.render()
+}
+
+// add counts a finished check. Results outside a round, like warmup
+// checks, are ignored.
+func (p *progressLine) add(healthcheck.Result) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.total == 0 {
+		return
+	}
+	p.done++
+	p.render()
+}
+
+// finish ends the round and blanks the line, so output printed after it
+// starts on a clean line
+func (p *progressLine) finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.width > 0 {
+		fmt.Fprintf(p.w, "\r%s\r", strings.Repeat(" ", p.width))
+	}
+	p.total, p.done, p.width = 0, 0, 0
+}
+
+func (p *progressLine) render() {
+	line := fmt.Sprintf("checked %d/%d", p.done, p.total)
+	// Pad over anything left from a longer line
+	fmt.Fprintf(p.w, "\r%-*s", p.width, line)
+	p.width = max(p.width, len(line))
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// pushgatewayJob is the job label metrics are grouped under
+const pushgatewayJob = "healthcheck"
+
+// pushgateway pushes each round's metrics to a Prometheus Pushgateway, for
+// cron jobs that aren't around to be scraped
+type pushgateway struct {
+	url string
+}
+
+// newPushgateway returns nil when no gateway is configured
+func newPushgateway(gateway string) (*pushgateway, error) {
+	if gateway == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(gateway)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid pushgateway %q: expected a URL like http://host:9091", gateway)
+	}
+	return &pushgateway{url: strings.TrimSuffix(gateway, "/") + "/metrics/job/" + pushgatewayJob}, nil
+}
+
+// push replaces the job's metrics with this round's. Failures are logged,
+// never returned, so an unreachable gateway doesn't fail the checks.
+func (p *pushgateway) push(ctx context.Context, results []healthcheck.Result) {
+	if p == nil {
+		return
+	}
+
+	// A cancelled or skipped check says nothing about the endpoint, so leave it out
+	// rather than pushing it as down
+	var checked []healthcheck.Result
+	for _, result := range results {
+		if !result.Cancelled() && !result.Skipped {
+			checked = append(checked, result)
+		}
+	}
+	if len(checked) == 0 {
+		return
+	}
+
+	if err := p.put(ctx, formatPrometheus(checked)); err != nil {
+		logger.Warn("failed to push metrics", "error", err)
+		return
+	}
+	logger.Debug("pushed metrics", "url", p.url, "endpoints", len(checked))
+}
+
+func (p *pushgateway) put(ctx context.Context, metrics string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+
+	// PUT replaces every metric in the group, so endpoints that were
+	// removed from the config don't linger
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.url, strings.NewReader(metrics))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("pushgateway returned status %d", resp.StatusCode)
+	}
+	return nil
+}
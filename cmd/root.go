@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -14,11 +15,44 @@ var rootCmd = &cobra.Command{
 and reports their health status with response times.
 
 Run 'healthcheck check' to perform health checks on configured endpoints.`,
+	// Errors are printed once by Execute instead of by cobra as well
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// cobra only handles --version on the root command itself
+		if showVersion {
+			fmt.Println(versionInfo())
+			os.Exit(0)
+		}
+		if err := applyEnv(cmd); err != nil {
+			return err
+		}
+		return setLogLevel(logLevelName)
+	},
 }
 
+// Flags shared by every subcommand
+var (
+	logLevelName string
+	showVersion  bool
+)
+
+func init() {
+	rootCmd.Version = version
+	rootCmd.SetVersionTemplate(versionInfo() + "\n")
+
+	rootCmd.PersistentFlags().StringVar(&logLevelName, "log-level", "info", "Log level for diagnostics on stderr: debug, info, warn or error")
+	rootCmd.PersistentFlags().BoolVar(&showVersion, "version", false, "Print version and build information")
+}
+
+// errSilentExit exits 1 without printing an error, for commands whose
+// output already says what went wrong
+var errSilentExit = errors.New("exit status 1")
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		if !errors.Is(err, errSilentExit) {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
 		os.Exit(1)
 	}
 }
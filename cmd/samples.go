@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// sampleStats is one endpoint's latency profile over --samples checks
+type sampleStats struct {
+	Endpoint healthcheck.Endpoint
+	// Summary covers just this endpoint's samples
+	Summary Summary
+	StdDev  time.Duration
+}
+
+// runSamples checks every endpoint n times, spread over the workers like
+// any other run, and reports latency stats per endpoint
+func runSamples(cmd *cobra.Command, out *outputWriter, opts runOptions, checker *healthcheck.Checker, endpoints []healthcheck.Endpoint, n int) error {
+	queue := repeatEndpoints(endpoints, n)
+	opts.progress.start(len(queue))
+	results := checker.Check(cmd.Context(), queue)
+	opts.progress.finish()
+
+	byEndpoint := make(map[string][]healthcheck.Result, len(endpoints))
+	for _, result := range results {
+		key := resultKey(result)
+		byEndpoint[key] = append(byEndpoint[key], result)
+	}
+
+	stats := make([]sampleStats, 0, len(endpoints))
+	for _, ep := range endpoints {
+		samples := byEndpoint[endpointKey(ep)]
+		stats = append(stats, sampleStats{
+			Endpoint: ep,
+			Summary:  summarize(samples),
+			StdDev:   latencyStdDev(samples),
+		})
+	}
+
+	summary := summarize(results)
+	var err error
+	switch {
+	case opts.format == "json":
+		err = printSamplesJSON(out, stats)
+	// Like the summary, quiet mode only shows the table when a sample failed
+	case !opts.quiet || summary.Unhealthy > 0:
+		err = printSamples(out, stats, n)
+	}
+	if err != nil {
+		return err
+	}
+
+	if policyFails(opts, summary) {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d samples failed", summary.Unhealthy, summary.Total)
+	}
+	return nil
+}
+
+// repeatEndpoints lists every endpoint n times, interleaved so that each
+// one's checks are spread over the run
+func repeatEndpoints(endpoints []healthcheck.Endpoint, n int) []healthcheck.Endpoint {
+	queue := make([]healthcheck.Endpoint, 0, len(endpoints)*n)
+	for range n {
+		queue = append(queue, endpoints...)
+	}
+	return queue
+}
+
+// warmUp checks every endpoint n times and throws the results away, so
+// the measured run reuses connections that already have their DNS lookup,
+// connect and TLS handshake done
+func warmUp(ctx context.Context, checker *healthcheck.Checker, endpoints []healthcheck.Endpoint, n int) {
+	logger.Debug("warming up", "checks", len(endpoints)*n)
+	checker.Check(ctx, repeatEndpoints(endpoints, n))
+}
+
+// latencyStdDev is the standard deviation of the samples that got a response
+func latencyStdDev(samples []healthcheck.Result) time.Duration {
+	var durations []float64
+	var total float64
+	for _, s := range samples {
+		if s.Responded() {
+			durations = append(durations, float64(s.Duration))
+			total += float64(s.Duration)
+		}
+	}
+	if len(durations) < 2 {
+		return 0
+	}
+
+	mean := total / float64(len(durations))
+	var squares float64
+	for _, d := range durations {
+		squares += (d - mean) * (d - mean)
+	}
+	return time.Duration(math.Sqrt(squares / float64(len(durations))))
+}
+
+func printSamples(w io.Writer, stats []sampleStats, n int) error {
+	fmt.Fprintf(w, "Latency over %d samples per endpoint\n", n)
+	fmt.Fprintln(w, "━━━━━━━━━━━━━━━━━━━━━━━")
+
+	round := func(d time.Duration) string {
+		return d.Round(time.Microsecond).String()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tHEALTHY\tMIN\tAVG\tP50\tP95\tMAX\tSTDDEV")
+	for _, st := range stats {
+		s := st.Summary
+		healthy := fmt.Sprintf("%d/%d", s.Healthy, s.Checked())
+		if s.Responses == 0 {
+			fmt.Fprintf(tw, "%s\t%s\t-\t-\t-\t-\t-\t-\n", st.Endpoint.Name, healthy)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", st.Endpoint.Name, healthy,
+			round(s.Min), round(s.Avg), round(s.P50), round(s.P95), round(s.Max), round(st.StdDev))
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write samples: %w", err)
+	}
+	return nil
+}
+
+// jsonSampleStats is the JSON representation of sampleStats. Latencies are
+// fractional milliseconds and left out when no sample got a response.
+type jsonSampleStats struct {
+	Name      string   `json:"name"`
+	URL       string   `json:"url"`
+	Samples   int      `json:"samples"`
+	Healthy   int      `json:"healthy"`
+	Unhealthy int      `json:"unhealthy"`
+	MinMs     *float64 `json:"min_ms,omitempty"`
+	AvgMs     *float64 `json:"avg_ms,omitempty"`
+	P50Ms     *float64 `json:"p50_ms,omitempty"`
+	P95Ms     *float64 `json:"p95_ms,omitempty"`
+	MaxMs     *float64 `json:"max_ms,omitempty"`
+	StdDevMs  *float64 `json:"stddev_ms,omitempty"`
+}
+
+func printSamplesJSON(w io.Writer, stats []sampleStats) error {
+	ms := func(d time.Duration) *float64 {
+		v := float64(d.Microseconds()) / 1000
+		return &v
+	}
+
+	out := make([]jsonSampleStats, 0, len(stats))
+	for _, st := range stats {
+		s := st.Summary
+		js := jsonSampleStats{
+			Name:      st.Endpoint.Name,
+			URL:       st.Endpoint.URL,
+			Samples:   s.Checked(),
+			Healthy:   s.Healthy,
+			Unhealthy: s.Unhealthy,
+		}
+		if s.Responses > 0 {
+			js.MinMs, js.AvgMs, js.P50Ms = ms(s.Min), ms(s.Avg), ms(s.P50)
+			js.P95Ms, js.MaxMs, js.StdDevMs = ms(s.P95), ms(s.Max), ms(st.StdDev)
+		}
+		out = append(out, js)
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode samples: %w", err)
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// shutdownTimeout is how long in-flight requests get to finish on shutdown
+const shutdownTimeout = 15 * time.Second
+
+var (
+	servePort int
+	serveHost string
+	cacheTTL  time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve health check results over HTTP",
+	Long: `Start an HTTP server that runs the configured health checks on every
+request and returns the results as JSON. The response is 200 when every
+endpoint is healthy and 503 otherwise, so it can back a load balancer or
+uptime monitor.
+
+Examples:
+  healthcheck serve --config endpoints.yaml
+  healthcheck serve --port 9000 --urls https://api.example.com/health
+  healthcheck serve --config endpoints.yaml --cache-ttl 30s
+  curl -i http://localhost:8080/`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	addEndpointFlags(serveCmd.Flags())
+	registerNameCompletion(serveCmd)
+	serveCmd.Flags().IntVar(&servePort, "port", 8080, "Port to listen on")
+	serveCmd.Flags().StringVar(&serveHost, "host", "", "Address to listen on (default all interfaces)")
+	serveCmd.Flags().DurationVar(&cacheTTL, "cache-ttl", 0, "Reuse each endpoint's result for this long instead of checking on every request")
+}
+
+// serveResponse is the JSON body returned by serve
+type serveResponse struct {
+	Timestamp time.Time `json:"timestamp"`
+	// CacheAge is how old the oldest result is; zero without --cache-ttl
+	CacheAge float64      `json:"cache_age_seconds"`
+	Summary  jsonSummary  `json:"summary"`
+	Results  []jsonResult `json:"results"`
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if servePort < 1 || servePort > 65535 {
+		return fmt.Errorf("invalid port %d: must be between 1 and 65535", servePort)
+	}
+	if cacheTTL < 0 {
+		return fmt.Errorf("invalid cache TTL %v: must not be negative", cacheTTL)
+	}
+
+	endpoints, err := loadEndpoints()
+	if err != nil {
+		return err
+	}
+	checker, err := newChecker()
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              net.JoinHostPort(serveHost, strconv.Itoa(servePort)),
+		Handler:           serveHandler(newResultCache(checker, endpoints, cacheTTL)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Flags are fine from here on, so failures shouldn't print usage
+	cmd.SilenceUsage = true
+
+	// Listen first so a busy port is reported before we claim to be serving
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(ln)
+	}()
+	logger.Info("serving health checks", "addr", ln.Addr().String(), "endpoints", len(endpoints))
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	// Stop accepting connections and let checks in flight finish
+	logger.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown failed: %w", err)
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
+
+// serveHandler checks every endpoint per request, or reuses cached results
+// with --cache-ttl. Without a cache a client that hangs up aborts its checks.
+func serveHandler(cache *resultCache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		results, age := cache.Results(r.Context())
+		sortResults(results, "name")
+		summary := summarize(results)
+
+		data, err := json.MarshalIndent(serveResponse{
+			Timestamp: time.Now().UTC(),
+			CacheAge:  age.Seconds(),
+			Summary:   toJSONSummary(summary),
+			Results:   toJSONResults(results),
+		}, "", "  ")
+		if err != nil {
+			logger.Error("failed to encode results", "error", err)
+			http.Error(w, "failed to encode results", http.StatusInternalServerError)
+			return
+		}
+
+		status := http.StatusOK
+		if summary.Unhealthy > 0 {
+			status = http.StatusServiceUnavailable
+		}
+		logger.Debug("served checks", "remote", r.RemoteAddr, "status", status, "unhealthy", summary.Unhealthy)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if cache.ttl > 0 {
+			w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+		}
+		w.WriteHeader(status)
+		w.Write(append(data, '\n'))
+	})
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// endpointStability tallies one endpoint's results across runs
+type endpointStability struct {
+	Name      string
+	URL       string
+	Healthy   int
+	Unhealthy int
+
+	// Latency only counts runs that got a response
+	responses int
+	total     time.Duration
+}
+
+// AvgLatency is the mean response time over the runs that got a response
+func (e *endpointStability) AvgLatency() time.Duration {
+	if e.responses == 0 {
+		return 0
+	}
+	return e.total / time.Duration(e.responses)
+}
+
+// StabilityReport accumulates results over repeat or watch runs so flaky
+// endpoints stand out from ones that are consistently up or down
+type StabilityReport struct {
+	Runs      int
+	endpoints map[string]*endpointStability
+	// order keeps endpoints in the order they were first seen
+	order []string
+}
+
+func newStabilityReport() *StabilityReport {
+	return &StabilityReport{endpoints: make(map[string]*endpointStability)}
+}
+
+// Add records one run's results. Cancelled and skipped checks say nothing
+// about the endpoint, so they aren't counted either way.
+func (r *StabilityReport) Add(results []healthcheck.Result) {
+	r.Runs++
+	for _, result := range results {
+		if result.Cancelled() || result.Skipped {
+			continue
+		}
+
+		key := resultKey(result)
+		e, ok := r.endpoints[key]
+		if !ok {
+			e = &endpointStability{Name: result.Endpoint.Name, URL: result.Endpoint.URL}
+			r.endpoints[key] = e
+			r.order = append(r.order, key)
+		}
+
+		if result.IsHealthy {
+			e.Healthy++
+		} else {
+			e.Unhealthy++
+		}
+		if result.Responded() {
+			e.responses++
+			e.total += result.Duration
+		}
+	}
+}
+
+// Failed reports whether any endpoint was unhealthy on any run
+func (r *StabilityReport) Failed() bool {
+	for _, e := range r.endpoints {
+		if e.Unhealthy > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Flaky lists endpoints that were healthy on some runs and unhealthy on others
+func (r *StabilityReport) Flaky() []*endpointStability {
+	var flaky []*endpointStability
+	for _, key := range r.order {
+		if e := r.endpoints[key]; e.Healthy > 0 && e.Unhealthy > 0 {
+			flaky = append(flaky, e)
+		}
+	}
+	return flaky
+}
+
+func printStability(w io.Writer, r *StabilityReport) error {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Stability over %d runs\n", r.Runs)
+	fmt.Fprintln(w, "━━━━━━━━━━━━━━━━━━━━━━━")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tHEALTHY\tUNHEALTHY\tAVG LATENCY")
+	for _, key := range r.order {
+		e := r.endpoints[key]
+		latency := "-"
+		if e.responses > 0 {
+			latency = e.AvgLatency().Round(time.Microsecond).String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", e.Name, e.Healthy, e.Unhealthy, latency)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write stability report: %w", err)
+	}
+
+	if flaky := r.Flaky(); len(flaky) > 0 {
+		fmt.Fprintf(w, "\n%s %d flaky endpoint(s)\n", colorize(colorYellow, "⚠"), len(flaky))
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// Summary aggregates the results of a run
+type Summary struct {
+	Total     int
+	Healthy   int
+	Unhealthy int
+	Degraded  int
+	Cancelled int
+	// Skipped counts endpoints not checked because a dependency wasn't
+	// healthy. Like cancelled checks, they aren't unhealthy.
+	Skipped int
+	// HealthyWeight and TotalWeight add up the weights of the healthy and
+	// all checked endpoints, for Score. Weighted is set when any endpoint
+	// has a weight of its own.
+	HealthyWeight int
+	TotalWeight   int
+	Weighted      bool
+	// StatusCodes counts the results with each status code, with those
+	// that got none (no response, or not an HTTP check) under 0
+	StatusCodes map[int]int
+
+	// Latency stats only cover checks that got a response
+	Responses int
+	Min       time.Duration
+	Avg       time.Duration
+	Max       time.Duration
+	P50       time.Duration
+	P95       time.Duration
+}
+
+func summarize(results []healthcheck.Result) Summary {
+	s := Summary{Total: len(results), StatusCodes: make(map[int]int)}
+
+	var durations []time.Duration
+	for _, result := range results {
+		if !result.Cancelled() && !result.Skipped {
+			s.StatusCodes[result.StatusCode]++
+		}
+
+		weight := max(result.Endpoint.Weight, 1)
+		s.Weighted = s.Weighted || result.Endpoint.Weight != 0
+
+		switch {
+		case result.Cancelled():
+			s.Cancelled++
+		case result.Skipped:
+			s.Skipped++
+		case !result.IsHealthy:
+			s.Unhealthy++
+			s.TotalWeight += weight
+		default:
+			s.Healthy++
+			s.HealthyWeight += weight
+			s.TotalWeight += weight
+			if result.Degraded {
+				s.Degraded++
+			}
+		}
+
+		if result.Responded() {
+			durations = append(durations, result.Duration)
+		}
+	}
+
+	s.Responses = len(durations)
+	if s.Responses == 0 {
+		return s
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	s.Min = durations[0]
+	s.Max = durations[len(durations)-1]
+	s.Avg = total / time.Duration(len(durations))
+	s.P50 = percentile(durations, 50)
+	s.P95 = percentile(durations, 95)
+	return s
+}
+
+// Checked is how many checks finished, leaving out cancelled and skipped ones
+func (s Summary) Checked() int {
+	return s.Total - s.Cancelled - s.Skipped
+}
+
+// UnhealthyPercent is the share of checks that finished that were unhealthy
+func (s Summary) UnhealthyPercent() float64 {
+	if s.Checked() == 0 {
+		return 0
+	}
+	return 100 * float64(s.Unhealthy) / float64(s.Checked())
+}
+
+// Score is the weighted percentage of checked endpoints that were healthy,
+// or 100 when nothing was checked
+func (s Summary) Score() float64 {
+	if s.TotalWeight == 0 {
+		return 100
+	}
+	return 100 * float64(s.HealthyWeight) / float64(s.TotalWeight)
+}
+
+// percentile uses the nearest-rank method on sorted durations, so small
+// samples return a real measurement rather than an interpolated one
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func printSummary(w io.Writer, s Summary, elapsed time.Duration, opts runOptions) {
+	fmt.Fprintln(w, "━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Fprintf(w, "✓ Checked %d endpoints in %v\n", s.Total, elapsed.Round(time.Microsecond))
+	fmt.Fprintf(w, "  Healthy: %d  Unhealthy: %d  Degraded: %d", s.Healthy, s.Unhealthy, s.Degraded)
+	if s.Skipped > 0 {
+		fmt.Fprintf(w, "  Skipped: %d", s.Skipped)
+	}
+	if s.Cancelled > 0 {
+		fmt.Fprintf(w, "  Cancelled: %d", s.Cancelled)
+	}
+	fmt.Fprintln(w)
+
+	if s.Responses > 0 {
+		fmt.Fprintf(w, "  Latency: min %v  avg %v  max %v  p50 %v  p95 %v\n",
+			s.Min.Round(time.Microsecond), s.Avg.Round(time.Microsecond), s.Max.Round(time.Microsecond),
+			s.P50.Round(time.Microsecond), s.P95.Round(time.Microsecond))
+	}
+
+	if opts.failThreshold > 0 {
+		fmt.Fprintf(w, "  Unhealthy: %.1f%% (fail threshold %g%%)\n", s.UnhealthyPercent(), opts.failThreshold)
+	}
+	if opts.minScore > 0 {
+		fmt.Fprintf(w, "  Score: %.1f%% (min score %g%%)\n", s.Score(), opts.minScore)
+	} else if s.Weighted {
+		fmt.Fprintf(w, "  Score: %.1f%%\n", s.Score())
+	}
+	if opts.countCodes && len(s.StatusCodes) > 0 {
+		var counts []string
+		for _, code := range slices.Sorted(maps.Keys(s.StatusCodes)) {
+			if code != 0 {
+				counts = append(counts, fmt.Sprintf("%d: %d", code, s.StatusCodes[code]))
+			}
+		}
+		// Results without a status code go last, after the real ones
+		if n := s.StatusCodes[0]; n > 0 {
+			counts = append(counts, fmt.Sprintf("none: %d", n))
+		}
+		fmt.Fprintf(w, "  Status Codes: %s\n", strings.Join(counts, "  "))
+	}
+}
+
+// statusCodeLabel names a StatusCodes key, where 0 means there was no status
+func statusCodeLabel(code int) string {
+	if code == 0 {
+		return "none"
+	}
+	return strconv.Itoa(code)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// templateResult is what --output-template is executed with: the full
+// Result, plus shorthands for the fields most templates want
+type templateResult struct {
+	healthcheck.Result
+	Name string
+	URL  string
+	// Status is "healthy", "degraded", "unhealthy", "skipped" or "cancelled"
+	Status string
+}
+
+// templateFuncs are the helpers available to --output-template
+var templateFuncs = template.FuncMap{
+	// ms gives a duration in fractional milliseconds, e.g. for printf
+	"ms": func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	},
+	// round rounds a duration to the millisecond, e.g. 142ms
+	"round": func(d time.Duration) time.Duration {
+		return d.Round(time.Millisecond)
+	},
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// parseOutputTemplate parses --output-template and tries it on an empty
+// result, so a syntax error or misspelt field is reported before anything
+// is checked
+func parseOutputTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output-template").Funcs(templateFuncs).Parse(text)
+	if err == nil {
+		err = tmpl.Execute(io.Discard, templateResult{})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid output-template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// printTemplate executes the template once per result, ending each with a
+// newline unless the template already does. Quiet mode skips healthy results.
+func printTemplate(w io.Writer, tmpl *template.Template, results []healthcheck.Result, quiet bool) error {
+	for _, result := range results {
+		if quiet && result.IsHealthy && !result.Degraded {
+			continue
+		}
+
+		tr := templateResult{
+			Result: result,
+			Name:   result.Endpoint.Name,
+			URL:    result.Endpoint.URL,
+			Status: resultStatus(result),
+		}
+
+		var b strings.Builder
+		if err := tmpl.Execute(&b, tr); err != nil {
+			return fmt.Errorf("failed to execute output-template for %s: %w", result.Endpoint.Name, err)
+		}
+		out := b.String()
+		if !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		if _, err := io.WriteString(w, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resultStatus names the state a result is in, worst first
+func resultStatus(result healthcheck.Result) string {
+	switch {
+	case result.Cancelled():
+		return "cancelled"
+	case result.Skipped:
+		return "skipped"
+	case !result.IsHealthy:
+		return "unhealthy"
+	case result.Degraded:
+		return "degraded"
+	default:
+		return "healthy"
+	}
+}
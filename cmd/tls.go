@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// loadClientCert loads the --client-cert and --client-key pair for mutual
+// TLS. Neither means no client certificate.
+func loadClientCert(certFile, keyFile string) ([]tls.Certificate, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("--client-cert and --client-key must be given together")
+	}
+
+	// This also catches a key that doesn't belong to the certificate
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate %s with key %s: %w", certFile, keyFile, err)
+	}
+	return []tls.Certificate{cert}, nil
+}
+
+// loadCAPool returns the system roots plus the PEM certificates in caFile,
+// or nil, meaning just the system roots, when there is no file
+func loadCAPool(caFile string) (*x509.CertPool, error) {
+	if caFile == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		// Some platforms have no system pool; the custom CA alone still works
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", caFile)
+	}
+	return pool, nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// schemes are the endpoint URL schemes there is a check for
+var schemes = []string{"http", "https", "tcp", "dns", "grpc", "ping"}
+
+// normalizeURL cleans up a URL as typed and checks it is valid. A missing
+// scheme means https, so "example.com" becomes "https://example.com", and
+// the scheme is lowercased, since checks are chosen by it.
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("URL is empty")
+	}
+	// Look for :// rather than asking url.Parse, which reads host:port as scheme:opaque
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		raw = "https://" + raw
+	} else {
+		// Only the scheme, so the rest is checked exactly as written
+		raw = strings.ToLower(scheme) + "://" + rest
+	}
+	if err := validateURL(raw); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// validateURL reports whether an endpoint URL is something we know how to
+// check, without touching the network
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("unsupported scheme %q in %s: must be one of %s", u.Scheme, raw, strings.Join(schemes, ", "))
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("URL %s has no host", raw)
+	}
+
+	// These checks connect to a port directly, so there's no default to fall back on
+	switch u.Scheme {
+	case "tcp", "grpc":
+		if u.Port() == "" {
+			return fmt.Errorf("%s endpoint %s has no port", u.Scheme, raw)
+		}
+	}
+	return nil
+}
+
+// printDryRun lists the endpoints that would be checked, failing if any
+// of them is invalid
+func printDryRun(out *outputWriter, endpoints []healthcheck.Endpoint) error {
+	fmt.Fprintf(out, "Would check %d endpoints:\n\n", len(endpoints))
+
+	invalid := 0
+	for _, ep := range endpoints {
+		if err := ep.Invalid(); err != nil {
+			invalid++
+			fmt.Fprintf(out, "%s [%s]\n", colorize(colorRed, "✗ INVALID"), ep.Name)
+			if ep.URL != "" {
+				fmt.Fprintf(out, "  URL: %s\n", ep.URL)
+			}
+			fmt.Fprintf(out, "  Error: %v\n\n", err)
+			continue
+		}
+
+		fmt.Fprintf(out, "%s [%s]\n", colorize(colorGreen, "✓ VALID"), ep.Name)
+		fmt.Fprintf(out, "  URL: %s\n", ep.URL)
+		if strings.HasPrefix(ep.URL, "http") {
+			fmt.Fprintf(out, "  Method: %s\n", ep.Method)
+		}
+		fmt.Fprintf(out, "  Timeout: %v\n\n", time.Duration(ep.Timeout))
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d endpoints are invalid", invalid, len(endpoints))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// placeholderPattern matches a {name} placeholder for a --var
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_-]*)\}`)
+
+// parseVars parses "name=value" --var flags into a map
+func parseVars(raw []string) (map[string]string, error) {
+	vars := make(map[string]string, len(raw))
+	for _, v := range raw {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || !placeholderPattern.MatchString("{"+name+"}") {
+			return nil, fmt.Errorf("invalid --var %q: expected name=value", v)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// expandVars replaces the {name} placeholders in s with their values,
+// returning the names that have none. Those placeholders are left as they are.
+func expandVars(s string, vars map[string]string) (string, []string) {
+	var unresolved []string
+	expanded := placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, ok := vars[name]
+		if !ok {
+			unresolved = append(unresolved, name)
+			return placeholder
+		}
+		return value
+	})
+	return expanded, unresolved
+}
+
+// expandEndpointVars fills in the --var placeholders in each endpoint's
+// URL and name, and in its depends_on so they still match. Every
+// unresolved placeholder is reported at once unless allowUnresolved is set.
+func expandEndpointVars(endpoints []healthcheck.Endpoint) error {
+	vars, err := parseVars(templateVars)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range endpoints {
+		ep := &endpoints[i]
+		if ep.Invalid() != nil {
+			continue
+		}
+
+		name := ep.Name
+		if missing := expandEndpoint(ep, vars); len(missing) > 0 && !allowUnresolved {
+			errs = append(errs, fmt.Errorf("%s: no --var for {%s}", name, strings.Join(missing, "}, {")))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("unresolved placeholders (use --allow-unresolved to check them as written): %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// expandEndpoint fills in the placeholders in an endpoint's URL, name and
+// depends_on, returning the names that have no value
+func expandEndpoint(ep *healthcheck.Endpoint, vars map[string]string) []string {
+	var missing []string
+	expand := func(s string) string {
+		expanded, unresolved := expandVars(s, vars)
+		for _, name := range unresolved {
+			if !slices.Contains(missing, name) {
+				missing = append(missing, name)
+			}
+		}
+		return expanded
+	}
+
+	ep.URL = expand(ep.URL)
+	ep.Name = expand(ep.Name)
+	if len(ep.DependsOn) > 0 {
+		// A copy, so endpoints expanded from one matrix entry don't share it
+		deps := make([]string, len(ep.DependsOn))
+		for i, dep := range ep.DependsOn {
+			deps[i] = expand(dep)
+		}
+		ep.DependsOn = deps
+	}
+	return missing
+}
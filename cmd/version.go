@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Build information, injected at build time with:
+//
+//	go build -ldflags "-X cli-healthchecker/cmd.version=v1.2.3 -X cli-healthchecker/cmd.commit=$(git rev-parse --short HEAD) -X cli-healthchecker/cmd.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "v0.1"
+	commit    = "none"
+	buildDate = "unknown"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version and build information",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(versionInfo())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
+
+func versionInfo() string {
+	return fmt.Sprintf("healthcheck %s\n  commit: %s\n  built:  %s", version, commit, buildDate)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"cli-healthchecker/pkg/healthcheck"
+)
+
+// Flags for wait
+var (
+	waitTotal time.Duration
+	waitPoll  time.Duration
+)
+
+var errWaitTimeout = errors.New("wait timeout reached")
+
+var waitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Check endpoints until they are all healthy, or give up",
+	Long: `Checks the endpoints every --poll interval until every one of them is
+healthy or degraded, then exits 0. Exits 1 if --timeout-total passes first,
+naming the endpoints that never came up. Once an endpoint is healthy it
+isn't checked again. Endpoints are chosen and checked with the same flags
+as 'check'.
+
+Examples:
+  healthcheck wait --urls https://app.example.com/health --timeout-total 120s --poll 2s
+  healthcheck wait --config healthcheck.yaml --tag env:staging && ./run-smoke-tests.sh
+  healthcheck wait -q --urls tcp://localhost:5432`,
+	RunE: runWait,
+}
+
+func init() {
+	rootCmd.AddCommand(waitCmd)
+
+	addEndpointFlags(waitCmd.Flags())
+	// An endpoint that can't be checked can never become healthy
+	waitCmd.Flags().MarkHidden("strict-config")
+	waitCmd.Flags().DurationVar(&waitTotal, "timeout-total", 5*time.Minute, "Most time to wait for every endpoint to become healthy")
+	waitCmd.Flags().DurationVar(&waitPoll, "poll", 2*time.Second, "How long to wait between checks")
+	waitCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Print nothing and only set the exit code")
+}
+
+func runWait(cmd *cobra.Command, args []string) error {
+	if waitTotal <= 0 {
+		return fmt.Errorf("invalid timeout-total %v: must be positive", waitTotal)
+	}
+	if waitPoll <= 0 {
+		return fmt.Errorf("invalid poll %v: must be positive", waitPoll)
+	}
+	strictConfig = true
+	endpoints, err := loadEndpoints()
+	if err != nil {
+		return err
+	}
+	checker, err := newChecker()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeoutCause(ctx, waitTotal, errWaitTimeout)
+	defer cancel()
+
+	w := cmd.OutOrStdout()
+	if quiet {
+		w = io.Discard
+	}
+	start := time.Now()
+	pending := endpoints
+	// last keeps each endpoint's latest finished result, for the error
+	// when it never comes up
+	last := make(map[string]healthcheck.Result, len(endpoints))
+
+	for attempt := 1; ; attempt++ {
+		var down []healthcheck.Endpoint
+		for _, result := range checker.Check(ctx, pending) {
+			if result.IsHealthy {
+				fmt.Fprintf(w, "✓ %s is healthy after %v\n", result.Endpoint.Name, time.Since(start).Round(time.Millisecond))
+				continue
+			}
+			// A check cut off by the timeout says less than the one before it
+			key := resultKey(result)
+			if _, seen := last[key]; ctx.Err() == nil || !seen {
+				last[key] = result
+			}
+			down = append(down, result.Endpoint)
+		}
+		pending = down
+		if len(pending) == 0 {
+			fmt.Fprintf(w, "All %d endpoints healthy after %v\n", len(endpoints), time.Since(start).Round(time.Millisecond))
+			return nil
+		}
+
+		if ctx.Err() == nil {
+			fmt.Fprintf(w, "Attempt %d: waiting for %s\n", attempt, waitingFor(pending))
+			select {
+			case <-ctx.Done():
+			case <-time.After(waitPoll):
+				continue
+			}
+		}
+		if !errors.Is(context.Cause(ctx), errWaitTimeout) {
+			return fmt.Errorf("wait cancelled: %w", context.Cause(ctx))
+		}
+		return waitTimeoutError(pending, len(endpoints), last)
+	}
+}
+
+// waitingFor names the endpoints still being waited for
+func waitingFor(pending []healthcheck.Endpoint) string {
+	names := make([]string, len(pending))
+	for i, ep := range pending {
+		names[i] = ep.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// waitTimeoutError lists the endpoints that never became healthy, each
+// with what was wrong the last time it was checked
+func waitTimeoutError(pending []healthcheck.Endpoint, total int, last map[string]healthcheck.Result) error {
+	errs := []error{fmt.Errorf("timed out after %v waiting for %d of %d endpoints", waitTotal, len(pending), total)}
+	for _, ep := range pending {
+		result := last[endpointKey(ep)]
+		switch {
+		case result.Error != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", ep.Name, result.Error))
+		case result.StatusCode != 0:
+			errs = append(errs, fmt.Errorf("%s: unhealthy with status %d", ep.Name, result.StatusCode))
+		default:
+			errs = append(errs, fmt.Errorf("%s: unhealthy", ep.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
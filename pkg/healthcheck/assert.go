@@ -0,0 +1,129 @@
+package healthcheck
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// NeedsBody reports whether any assertion requires reading the response body
+func (e Endpoint) NeedsBody() bool {
+	return e.ExpectBody != "" || e.ExpectBodyRegex != "" || len(e.ExpectJSON) > 0 ||
+		e.MinBodyBytes > 0 || e.MaxBodyBytes > 0
+}
+
+// checkBody reads the body and checks it against the endpoint's assertions,
+// returning how many bytes were read. At most limit bytes are read, or one
+// byte past MaxBodyBytes if that's larger, so an oversized body is detected
+// without loading all of it.
+func checkBody(endpoint Endpoint, body io.Reader, limit int64) (int64, error) {
+	if endpoint.MaxBodyBytes >= limit {
+		limit = endpoint.MaxBodyBytes + 1
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, limit))
+	size := int64(len(data))
+	if err != nil {
+		return size, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	if endpoint.MinBodyBytes > 0 && size < endpoint.MinBodyBytes {
+		return size, fmt.Errorf("body is %d bytes, expected at least %d", size, endpoint.MinBodyBytes)
+	}
+	if endpoint.MaxBodyBytes > 0 && size > endpoint.MaxBodyBytes {
+		return size, fmt.Errorf("body is larger than %d bytes", endpoint.MaxBodyBytes)
+	}
+
+	if endpoint.ExpectBody != "" && !strings.Contains(string(data), endpoint.ExpectBody) {
+		return size, fmt.Errorf("body does not contain %q", endpoint.ExpectBody)
+	}
+	if endpoint.bodyRegex != nil && !endpoint.bodyRegex.Match(data) {
+		return size, fmt.Errorf("body does not match /%s/", endpoint.ExpectBodyRegex)
+	}
+	if len(endpoint.jsonAsserts) > 0 {
+		if err := checkJSON(endpoint.jsonAsserts, data); err != nil {
+			return size, err
+		}
+	}
+	return size, nil
+}
+
+// headerAssertion is a parsed ExpectHeaders entry
+type headerAssertion struct {
+	name string
+	// value is compared only when hasValue is set
+	value    string
+	hasValue bool
+}
+
+// parseHeaderAssertion parses "Key: Value", or "Key" to just require the
+// header to be present
+func parseHeaderAssertion(expr string) (headerAssertion, error) {
+	name, value, hasValue := strings.Cut(expr, ":")
+	name = strings.TrimSpace(name)
+	if !httpguts.ValidHeaderFieldName(name) {
+		return headerAssertion{}, fmt.Errorf("invalid header assertion %q: expected \"Key: Value\" or \"Key\"", expr)
+	}
+	return headerAssertion{
+		name:     http.CanonicalHeaderKey(name),
+		value:    strings.TrimSpace(value),
+		hasValue: hasValue,
+	}, nil
+}
+
+// checkHeaders checks the response headers against the assertions. A
+// header sent more than once, or as a comma-separated list, passes if any
+// one of its values matches.
+func checkHeaders(asserts []headerAssertion, header http.Header) error {
+	for _, a := range asserts {
+		values := header.Values(a.name)
+		if len(values) == 0 {
+			return fmt.Errorf("response has no %s header", a.name)
+		}
+		if !a.hasValue || headerHasValue(values, a.value) {
+			continue
+		}
+		if len(values) == 1 {
+			return fmt.Errorf("header %s is %q, expected %q", a.name, values[0], a.value)
+		}
+		return fmt.Errorf("header %s is %q, expected one to be %q", a.name, values, a.value)
+	}
+	return nil
+}
+
+// headerHasValue reports whether want is one of the values, either whole
+// or as an element of a comma-separated list
+func headerHasValue(values []string, want string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) == want {
+			return true
+		}
+		for _, part := range strings.Split(v, ",") {
+			if strings.TrimSpace(part) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkContentType compares the media type of a Content-Type header with
+// the expected one, ignoring parameters such as charset
+func checkContentType(expected, header string) error {
+	if header == "" {
+		return fmt.Errorf("response has no Content-Type, expected %s", expected)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return fmt.Errorf("invalid Content-Type %q: %w", header, err)
+	}
+	if !strings.EqualFold(mediaType, expected) {
+		return fmt.Errorf("got Content-Type %s, expected %s", mediaType, expected)
+	}
+	return nil
+}
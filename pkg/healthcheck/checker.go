@@ -0,0 +1,638 @@
+// Package healthcheck checks HTTP, TCP, DNS, gRPC and ICMP endpoints.
+//
+//	checker := &healthcheck.Checker{Retries: 2}
+//	results := checker.Check(ctx, []healthcheck.Endpoint{
+//		{Name: "API", URL: "https://api.example.com/health"},
+//	})
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultTimeout applies to endpoints that don't set a Timeout
+	DefaultTimeout = 10 * time.Second
+	// DefaultConcurrency applies when Checker.Concurrency is zero
+	DefaultConcurrency = 10
+	// DefaultMaxReadBytes applies when Checker.MaxReadBytes is zero
+	DefaultMaxReadBytes = 1 << 20
+)
+
+// Checker runs health checks. The zero value checks each endpoint once,
+// ten at a time, with no diagnostics. A Checker is safe for concurrent use,
+// but its settings shouldn't change once it has started checking.
+type Checker struct {
+	// Retries is how many times a failed check is tried again
+	Retries int
+	// RetryDelay is the wait before the first retry
+	RetryDelay time.Duration
+	// Backoff doubles the retry delay after each attempt
+	Backoff bool
+	// RetryOn limits which failures are retried; nil means DefaultRetryOn
+	RetryOn *RetryPolicy
+	// RetryJitter adds a random amount up to this long to each retry
+	// delay, so endpoints failing together don't retry in lockstep
+	RetryJitter time.Duration
+	// Deadline caps the time spent on one endpoint across all its
+	// attempts and retry delays; zero means no cap. Attempts are cut
+	// short to fit, and no retry starts once the deadline would pass.
+	Deadline time.Duration
+
+	// Concurrency is the most checks to run at once
+	Concurrency int
+	// Jitter delays each check by a random amount up to this long, so
+	// checks against a shared backend don't all land at the same instant
+	Jitter time.Duration
+	// Rate caps how many attempts start per second across all workers,
+	// retries included; zero means no limit
+	Rate float64
+
+	// CertWarnDays marks HTTPS endpoints as degraded when their
+	// certificate expires within this many days; zero disables it
+	CertWarnDays int
+	// InsecureSkipVerify accepts any TLS certificate
+	InsecureSkipVerify bool
+	// ClientCertificates are presented to HTTPS servers that ask for one,
+	// for mutual TLS
+	ClientCertificates []tls.Certificate
+	// RootCAs verifies HTTPS servers' certificates; nil means the system roots
+	RootCAs *x509.CertPool
+	// NoRedirects reports redirect responses instead of following them
+	NoRedirects bool
+	// MaxRedirects is how many redirects are followed before giving up;
+	// zero means DefaultMaxRedirects
+	MaxRedirects int
+	// Network forces HTTP, TCP and gRPC checks to connect over IPv4 with
+	// "tcp4" or IPv6 with "tcp6", and DNS and ping checks to look up only
+	// that family; empty uses whichever the dialer picks
+	Network string
+	// Proxy is used for every HTTP request; nil means HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY are respected
+	Proxy *url.URL
+	// MaxReadBytes caps how much of a response body is read, whether for
+	// assertions or to drain it so the connection can be reused
+	MaxReadBytes int64
+	// DisableKeepAlives opens a new connection for every HTTP request
+	// rather than reusing pooled ones, so each check pays for its own
+	// DNS lookup, connect and TLS handshake
+	DisableKeepAlives bool
+	// UseCookies shares a cookie jar between HTTP requests, so a cookie set
+	// by one check, like a login, is sent by later checks of the same host.
+	// Checks only run in order with a Concurrency of 1.
+	UseCookies bool
+	// RequestIDHeader, when set, is sent with a new UUID on every HTTP
+	// request so a check can be found in the server's logs
+	RequestIDHeader string
+
+	// Logger receives debug diagnostics; nil discards them
+	Logger *slog.Logger
+	// OnResult, when set, is called with each result as soon as it's
+	// ready, one at a time, e.g. to show progress through a long run
+	OnResult func(Result)
+
+	// client is shared by every HTTP check, so connections are pooled
+	// across endpoints, retries and rounds
+	clientOnce sync.Once
+	client     *http.Client
+
+	limiterOnce sync.Once
+	limiter     *rate.Limiter
+
+	// attempt makes a single attempt at a check; nil means checkOnce.
+	// Tests set it to stand in for a real check.
+	attempt func(ctx context.Context, endpoint Endpoint) Result
+}
+
+func (c *Checker) maxReadBytes() int64 {
+	if c.MaxReadBytes <= 0 {
+		return DefaultMaxReadBytes
+	}
+	return c.MaxReadBytes
+}
+
+// network is what to dial, "tcp" unless Network forces a family
+func (c *Checker) network() string {
+	if c.Network == "" {
+		return "tcp"
+	}
+	return c.Network
+}
+
+// ipNetwork is the matching network for address lookups: "ip", "ip4" or "ip6"
+func (c *Checker) ipNetwork() string {
+	return "ip" + strings.TrimPrefix(c.network(), "tcp")
+}
+
+func (c *Checker) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return c.Logger
+}
+
+// Check checks every endpoint and returns a result for each, in the same
+// order as endpoints. Cancelling ctx aborts checks still in flight.
+//
+// An endpoint with DependsOn isn't checked until those endpoints have
+// been, and is skipped if any of them is unhealthy. Endpoints caught in a
+// dependency cycle are reported unhealthy without being checked.
+func (c *Checker) Check(ctx context.Context, endpoints []Endpoint) []Result {
+	order, deps, stuck := dependencyOrder(endpoints)
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	// results[i] is endpoint i's result, and done[i] is closed once it's
+	// there so dependents can read it
+	results := make([]Result, len(endpoints))
+	done := make([]chan struct{}, len(endpoints))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	record := func(i int, result Result) {
+		result.ErrorKind = classify(result)
+		results[i] = result
+		close(done[i])
+		if c.OnResult != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			c.OnResult(result)
+		}
+	}
+
+	if len(stuck) > 0 {
+		err := errors.New("dependency cycle")
+		if cycle := findCycle(endpoints); cycle != nil {
+			err = fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " → "))
+		}
+		for _, i := range stuck {
+			record(i, Result{Endpoint: endpoints[i], Error: err, ErrorKind: ErrorKindInvalid, Attempts: 1})
+		}
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	for i := 0; i < min(concurrency, len(order)); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result, blocked := c.waitForDependencies(ctx, endpoints[i], deps[i], done, results)
+				if !blocked {
+					result = c.safeCheck(ctx, endpoints[i])
+				}
+				record(i, result)
+			}
+		}()
+	}
+
+	// Every endpoint is queued after its dependencies, so the oldest one a
+	// worker is waiting on is always running, and waiting can't deadlock
+	for _, i := range order {
+		jobs <- i
+	}
+	// Closing the channel lets the workers exit once the queue drains
+	close(jobs)
+
+	wg.Wait()
+	return results
+}
+
+// cancelledError reports a check that ctx ended early. Only
+// context.Canceled makes it cancelled; any other cause, like the one
+// context.WithTimeoutCause gives a run timeout, means it ran out of time
+// and wraps errCheckTimedOut.
+func cancelledError(ctx context.Context) error {
+	cause := context.Cause(ctx)
+	if errors.Is(cause, context.Canceled) {
+		return fmt.Errorf("check cancelled: %w", cause)
+	}
+	return fmt.Errorf("%w: %w", errCheckTimedOut, cause)
+}
+
+// errCheckTimedOut marks checks cut short by their context running out of time
+var errCheckTimedOut = errors.New("check timed out")
+
+// waitForDependencies waits until the endpoint's dependencies have been
+// checked. If one of them wasn't healthy, it returns the result to report
+// instead of checking the endpoint.
+func (c *Checker) waitForDependencies(ctx context.Context, endpoint Endpoint, deps []int, done []chan struct{}, results []Result) (Result, bool) {
+	for _, d := range deps {
+		<-done[d]
+		dep := results[d]
+		switch {
+		case dep.IsHealthy:
+			continue
+		case dep.Cancelled():
+			return Result{Endpoint: endpoint, Error: fmt.Errorf("check cancelled: %w", context.Canceled)}, true
+		case dep.Skipped:
+			c.logger().Debug("skipping check", "name", endpoint.Name, "dependency", dep.Endpoint.Name)
+			return Result{Endpoint: endpoint, Skipped: true, Error: fmt.Errorf("skipped: %s was skipped", dep.Endpoint.Name)}, true
+		default:
+			c.logger().Debug("skipping check", "name", endpoint.Name, "dependency", dep.Endpoint.Name)
+			return Result{Endpoint: endpoint, Skipped: true, Error: fmt.Errorf("skipped: %s is unhealthy", dep.Endpoint.Name)}, true
+		}
+	}
+	return Result{}, false
+}
+
+// safeCheck runs checkEndpoint, turning a panic into an unhealthy result so
+// one bad check can't crash the run or leave its worker stuck
+func (c *Checker) safeCheck(ctx context.Context, endpoint Endpoint) (result Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger().Error("check panicked", "name", endpoint.Name, "url", endpoint.URL, "panic", r)
+			c.logger().Debug("panic stack", "stack", string(debug.Stack()))
+			result = Result{
+				Endpoint: endpoint,
+				Error:    fmt.Errorf("check panicked: %v", r),
+				Attempts: 1,
+			}
+		}
+	}()
+
+	if endpoint.invalid != nil {
+		return Result{Endpoint: endpoint, Error: endpoint.invalid, ErrorKind: ErrorKindInvalid, Attempts: 1}
+	}
+	if !endpoint.compiled {
+		if err := endpoint.Compile(); err != nil {
+			return Result{Endpoint: endpoint, Error: err, ErrorKind: ErrorKindInvalid, Attempts: 1}
+		}
+	}
+	if endpoint.Timeout <= 0 {
+		endpoint.Timeout = Duration(DefaultTimeout)
+	}
+
+	if c.Jitter > 0 {
+		// Waiting on ctx too means a shutdown isn't held up by the sleep
+		select {
+		case <-ctx.Done():
+			return Result{Endpoint: endpoint, Error: cancelledError(ctx), Attempts: 1}
+		case <-time.After(rand.N(c.Jitter)):
+		}
+	}
+	return c.checkEndpoint(ctx, endpoint)
+}
+
+// httpClient builds the client used for HTTP checks on first use. It has no
+// timeout of its own, since each endpoint has its own; checkHTTP applies it.
+func (c *Checker) httpClient() *http.Client {
+	c.clientOnce.Do(func() {
+		c.client = c.newHTTPClient()
+	})
+	return c.client
+}
+
+func (c *Checker) newHTTPClient() *http.Client {
+	client := &http.Client{CheckRedirect: c.checkRedirect}
+	if c.UseCookies {
+		// Without options New can't fail
+		client.Jar, _ = cookiejar.New(nil)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// An explicit proxy wins over the HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment
+	transport.Proxy = http.ProxyFromEnvironment
+	if c.Proxy != nil {
+		transport.Proxy = http.ProxyURL(c.Proxy)
+	}
+
+	if c.Network != "" {
+		// The same settings as the default transport's dialer
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, c.Network, addr)
+		}
+	}
+
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		Certificates:       c.ClientCertificates,
+		RootCAs:            c.RootCAs,
+	}
+
+	// Keep enough idle connections for every worker to reuse its own
+	transport.MaxIdleConnsPerHost = max(c.Concurrency, DefaultConcurrency)
+	transport.DisableKeepAlives = c.DisableKeepAlives
+
+	client.Transport = transport
+	return client
+}
+
+// waitForRate blocks until the Rate allows another attempt, or ctx is done
+func (c *Checker) waitForRate(ctx context.Context) error {
+	if c.Rate <= 0 {
+		return nil
+	}
+	c.limiterOnce.Do(func() {
+		// A burst of one spaces attempts out evenly rather than letting
+		// a second's worth start at once
+		c.limiter = rate.NewLimiter(rate.Limit(c.Rate), 1)
+	})
+	if err := c.limiter.Wait(ctx); err != nil {
+		if ctx.Err() != nil {
+			return cancelledError(ctx)
+		}
+		// The wait would have outlasted ctx's deadline
+		return fmt.Errorf("%w: %w", errCheckTimedOut, err)
+	}
+	return nil
+}
+
+// checkEndpoint checks an endpoint, retrying failed attempts up to the Retries limit
+func (c *Checker) checkEndpoint(ctx context.Context, endpoint Endpoint) Result {
+	delay := c.RetryDelay
+	var result Result
+
+	try := c.attempt
+	if try == nil {
+		try = c.checkOnce
+	}
+
+	var deadline time.Time
+	if c.Deadline > 0 {
+		deadline = time.Now().Add(c.Deadline)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := c.waitForRate(ctx); err != nil {
+			result = Result{Endpoint: endpoint, Error: err, Attempts: attempt}
+			return result
+		}
+
+		if deadline.IsZero() {
+			result = try(ctx, endpoint)
+		} else {
+			attemptEndpoint := endpoint
+			attemptEndpoint.Timeout = min(endpoint.Timeout, Duration(time.Until(deadline).Truncate(time.Millisecond)))
+			result = try(ctx, attemptEndpoint)
+			result.Endpoint = endpoint
+		}
+
+		// Over the fail threshold is a failure, whatever MaxLatency says
+		if limit := time.Duration(endpoint.MaxLatencyFail); limit > 0 && result.IsHealthy && result.Duration > limit {
+			result.IsHealthy = false
+			result.Error = fmt.Errorf("response took %v, over the %v limit", result.Duration.Round(time.Millisecond), limit)
+			result.ErrorKind = ErrorKindLatency
+		}
+		slow := endpoint.MaxLatency > 0 && result.Duration > time.Duration(endpoint.MaxLatency)
+		result.Degraded = result.IsHealthy && (result.Degraded || slow)
+		result.Attempts = attempt
+
+		if result.IsHealthy || result.Cancelled() || attempt > c.Retries {
+			return result
+		}
+
+		policy := c.RetryOn
+		if policy == nil {
+			policy = defaultRetryPolicy
+		}
+		retry, reason := policy.shouldRetry(result)
+		if !retry {
+			c.logger().Debug("not retrying check", "name", endpoint.Name, "status", result.StatusCode, "error", result.Error)
+			return result
+		}
+
+		wait := delay
+		if c.RetryJitter > 0 {
+			wait += rand.N(c.RetryJitter)
+		}
+
+		// No point waiting for a retry that would have no time left to run
+		if !deadline.IsZero() && time.Until(deadline) <= wait {
+			c.logger().Debug("not retrying check", "name", endpoint.Name, "reason", "deadline", "deadline", c.Deadline)
+			stopped := fmt.Sprintf("stopped retrying after %d attempts to stay within the %v deadline", attempt, c.Deadline)
+			if result.Error != nil {
+				result.Error = fmt.Errorf("%w (%s)", result.Error, stopped)
+			} else {
+				result.Error = errors.New(stopped)
+			}
+			return result
+		}
+
+		c.logger().Debug("retrying check", "name", endpoint.Name, "attempt", attempt+1, "delay", wait, "reason", reason)
+
+		// Don't keep a cancelled run waiting on the retry delay
+		select {
+		case <-ctx.Done():
+			result.Error = cancelledError(ctx)
+			return result
+		case <-time.After(wait):
+		}
+		if c.Backoff {
+			delay *= 2
+		}
+	}
+}
+
+// checkOnce makes a single attempt, dispatching on the endpoint's URL scheme
+func (c *Checker) checkOnce(ctx context.Context, endpoint Endpoint) Result {
+	c.logger().Debug("check started", "name", endpoint.Name, "url", endpoint.URL)
+
+	var result Result
+	switch {
+	case strings.HasPrefix(endpoint.URL, "tcp://"):
+		result = checkTCP(ctx, endpoint, c.network())
+	case strings.HasPrefix(endpoint.URL, "dns://"):
+		result = checkDNS(ctx, endpoint, c.ipNetwork())
+	case strings.HasPrefix(endpoint.URL, "grpc://"):
+		result = checkGRPC(ctx, endpoint, c.network())
+	case strings.HasPrefix(endpoint.URL, "ping://"):
+		result = c.checkPing(ctx, endpoint)
+	default:
+		result = c.checkHTTP(ctx, endpoint)
+	}
+
+	if result.Error != nil {
+		c.logger().Debug("check failed", "name", endpoint.Name, "status", result.StatusCode, "duration", result.Duration, "error", result.Error)
+	} else {
+		c.logger().Debug("check finished", "name", endpoint.Name, "status", result.StatusCode, "duration", result.Duration, "healthy", result.IsHealthy)
+	}
+	return result
+}
+
+// checkHTTP performs a single request; the endpoint's timeout applies to
+// each attempt, including reading the body
+func (c *Checker) checkHTTP(ctx context.Context, endpoint Endpoint) Result {
+	start := time.Now()
+
+	timeout := time.Duration(endpoint.Timeout)
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// A fresh reader per attempt means retries resend the whole body, and
+	// NewRequest sets GetBody from it so redirects can too
+	var body io.Reader
+	if endpoint.Body != "" {
+		body = strings.NewReader(endpoint.Body)
+	}
+
+	traceCtx, trace := withTimingTrace(attemptCtx)
+	traceCtx, chain := withRedirectChain(traceCtx)
+
+	// HEAD responses have no body, which the client already handles for us
+	req, err := http.NewRequestWithContext(traceCtx, endpoint.Method, endpoint.URL, body)
+	if err != nil {
+		return Result{
+			Endpoint:  endpoint,
+			IsHealthy: false,
+			Error:     err,
+			ErrorKind: ErrorKindInvalid,
+		}
+	}
+
+	if endpoint.UserAgent != "" {
+		req.Header.Set("User-Agent", endpoint.UserAgent)
+	}
+	for key, value := range endpoint.Headers {
+		req.Header.Set(key, value)
+	}
+	var requestID string
+	if c.RequestIDHeader != "" {
+		requestID = uuid.NewString()
+		req.Header.Set(c.RequestIDHeader, requestID)
+	}
+	if endpoint.Body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", bodyContentType(endpoint.Body))
+	}
+	if endpoint.Username != "" || endpoint.Password != "" {
+		req.SetBasicAuth(endpoint.Username, endpoint.Password)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	duration := time.Since(start)
+
+	// Wrap the context error so cancelled checks aren't mistaken for failures
+	if err != nil && ctx.Err() != nil {
+		err = cancelledError(ctx)
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %v: %w", timeout, err)
+	}
+
+	// Make it obvious when it was the proxy, not the endpoint, that failed
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		err = fmt.Errorf("proxy connection failed: %w", err)
+	}
+
+	if err != nil {
+		return Result{
+			Endpoint:   endpoint,
+			IsHealthy:  false,
+			Duration:   duration,
+			Error:      err,
+			Timings:    trace.Timings(),
+			RemoteAddr: trace.RemoteAddr(),
+			Redirects:  chain.Hops(),
+			RequestID:  requestID,
+		}
+	}
+	// Drain what's left of the body, up to the cap, so the connection can be
+	// reused by retries; a bigger body just costs a new connection
+	defer func() {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, c.maxReadBytes()))
+		resp.Body.Close()
+	}()
+
+	result := Result{
+		Endpoint:   endpoint,
+		IsHealthy:  statusHealthy(endpoint.expected, resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		Duration:   duration,
+		Error:      nil,
+		Timings:    trace.Timings(),
+		RemoteAddr: trace.RemoteAddr(),
+		Proto:      resp.Proto,
+		Redirects:  chain.Hops(),
+		RequestID:  requestID,
+	}
+
+	if !result.IsHealthy {
+		result.ErrorKind = ErrorKindStatus
+	}
+
+	if final := resp.Request.URL.String(); final != endpoint.URL {
+		result.FinalURL = final
+	}
+
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		result.CertExpiry = resp.TLS.PeerCertificates[0].NotAfter
+		result.CertDaysLeft = int(time.Until(result.CertExpiry).Hours() / 24)
+		result.Degraded = c.CertWarnDays > 0 && result.CertDaysLeft < c.CertWarnDays
+	}
+
+	if result.IsHealthy && endpoint.ExpectHTTP2 && resp.ProtoMajor != 2 {
+		result.IsHealthy = false
+		result.ErrorKind = ErrorKindAssertion
+		result.Error = fmt.Errorf("served over %s, expected HTTP/2", resp.Proto)
+		// HTTP/2 is negotiated during the TLS handshake, so plain HTTP never gets it
+		if resp.TLS == nil {
+			result.Error = fmt.Errorf("served over %s without TLS, expected HTTP/2 (which needs https)", resp.Proto)
+		}
+	}
+
+	if result.IsHealthy && endpoint.ExpectContentType != "" {
+		if err := checkContentType(endpoint.ExpectContentType, resp.Header.Get("Content-Type")); err != nil {
+			result.IsHealthy = false
+			result.Error = err
+			result.ErrorKind = ErrorKindAssertion
+		}
+	}
+
+	if result.IsHealthy && len(endpoint.headerAsserts) > 0 {
+		if err := checkHeaders(endpoint.headerAsserts, resp.Header); err != nil {
+			result.IsHealthy = false
+			result.Error = err
+			result.ErrorKind = ErrorKindAssertion
+		}
+	}
+
+	// Only bother reading the body when the status passed and something checks it
+	if result.IsHealthy && endpoint.NeedsBody() {
+		size, err := checkBody(endpoint, resp.Body, c.maxReadBytes())
+		result.BodyBytes, result.BodyRead = size, true
+		if err != nil {
+			result.IsHealthy = false
+			result.Error = err
+			// Failing to read the body is a network error, not an assertion
+			if result.ErrorKind = classifyError(err); result.ErrorKind == ErrorKindUnknown {
+				result.ErrorKind = ErrorKindAssertion
+			}
+		}
+	}
+
+	return result
+}
+
+// bodyContentType guesses a Content-Type for a request body
+func bodyContentType(body string) string {
+	if json.Valid([]byte(body)) {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
@@ -0,0 +1,236 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// checkOne runs a Checker over a single endpoint and returns its result
+func checkOne(t *testing.T, c *Checker, ep Endpoint) Result {
+	t.Helper()
+	results := c.Check(context.Background(), []Endpoint{ep})
+	if len(results) != 1 {
+		t.Fatalf("Check returned %d results, want 1", len(results))
+	}
+	return results[0]
+}
+
+func TestCheckStatusCodes(t *testing.T) {
+	tests := []struct {
+		code    int
+		healthy bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusNotFound, false},
+		{http.StatusInternalServerError, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.code), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.code)
+			}))
+			defer srv.Close()
+
+			result := checkOne(t, &Checker{}, Endpoint{Name: "test", URL: srv.URL, Timeout: Duration(time.Second)})
+			if result.IsHealthy != tt.healthy {
+				t.Errorf("IsHealthy = %v, want %v", result.IsHealthy, tt.healthy)
+			}
+			if result.StatusCode != tt.code {
+				t.Errorf("StatusCode = %d, want %d", result.StatusCode, tt.code)
+			}
+			// A failing status is reported by the code, not an error
+			if result.Error != nil {
+				t.Errorf("Error = %v, want nil", result.Error)
+			}
+		})
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	result := checkOne(t, &Checker{}, Endpoint{Name: "slow", URL: srv.URL, Timeout: Duration(50 * time.Millisecond)})
+	if result.IsHealthy {
+		t.Fatal("IsHealthy = true, want false")
+	}
+	if result.StatusCode != 0 {
+		t.Errorf("StatusCode = %d, want 0", result.StatusCode)
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "timed out after 50ms") {
+		t.Errorf("Error = %v, want a timeout", result.Error)
+	}
+	if !errors.Is(result.Error, context.DeadlineExceeded) {
+		t.Errorf("Error = %v, want it to wrap context.DeadlineExceeded", result.Error)
+	}
+}
+
+func TestCheckConnectionRefused(t *testing.T) {
+	// A listener's address that nothing listens on once it's closed
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	result := checkOne(t, &Checker{}, Endpoint{Name: "closed", URL: "http://" + addr, Timeout: Duration(time.Second)})
+	if result.IsHealthy {
+		t.Fatal("IsHealthy = true, want false")
+	}
+	if result.StatusCode != 0 {
+		t.Errorf("StatusCode = %d, want 0", result.StatusCode)
+	}
+	if !errors.Is(result.Error, syscall.ECONNREFUSED) {
+		t.Errorf("Error = %v, want connection refused", result.Error)
+	}
+}
+
+func TestCheckBody(t *testing.T) {
+	// Past the read limit, so "marker" at the end is never seen
+	large := strings.Repeat("x", DefaultMaxReadBytes) + "marker"
+	tests := []struct {
+		name     string
+		body     string
+		endpoint Endpoint
+		// maxRead sets Checker.MaxReadBytes when nonzero
+		maxRead  int64
+		wantErr  string
+		wantSize int64
+	}{
+		{"contains", `{"status":"ok"}`, Endpoint{ExpectBody: `"ok"`}, 0, "", 15},
+		{"does not contain", `{"status":"down"}`, Endpoint{ExpectBody: `"ok"`}, 0, `body does not contain "\"ok\""`, 17},
+		{"matches regex", "version 1.2.3", Endpoint{ExpectBodyRegex: `\d+\.\d+`}, 0, "", 13},
+		{"does not match regex", "version unknown", Endpoint{ExpectBodyRegex: `\d+\.\d+`}, 0, `body does not match /\d+\.\d+/`, 15},
+		{"too small", "ok", Endpoint{MinBodyBytes: 10}, 0, "body is 2 bytes, expected at least 10", 2},
+		{"too large", "0123456789", Endpoint{MaxBodyBytes: 5}, 0, "body is larger than 5 bytes", 10},
+		{"past the read limit", large, Endpoint{ExpectBody: "marker"}, 0, `body does not contain "marker"`, DefaultMaxReadBytes},
+		{"past a configured read limit", "0123456789 marker", Endpoint{ExpectBody: "marker"}, 8, `body does not contain "marker"`, 8},
+		{"JSON path matches", `{"status":"ok","checks":[{"up":true}]}`, Endpoint{ExpectJSON: []string{"status=ok", "$.checks[0].up=true"}}, 0, "", 38},
+		{"JSON path differs", `{"status":"down"}`, Endpoint{ExpectJSON: []string{"status=ok"}}, 0, `JSON path status is "down", expected ok`, 17},
+		{"JSON path missing", `{"checks":[]}`, Endpoint{ExpectJSON: []string{"checks[0].up=true"}}, 0, "JSON path checks.0 not found", 13},
+		{"not JSON", "ok", Endpoint{ExpectJSON: []string{"status=ok"}}, 0, "body is not valid JSON: invalid character 'o' looking for beginning of value", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			ep := tt.endpoint
+			ep.Name, ep.URL, ep.Timeout = "body", srv.URL, Duration(time.Second)
+			result := checkOne(t, &Checker{MaxReadBytes: tt.maxRead}, ep)
+			if result.StatusCode != http.StatusOK {
+				t.Errorf("StatusCode = %d, want 200", result.StatusCode)
+			}
+			if !result.BodyRead || result.BodyBytes != tt.wantSize {
+				t.Errorf("BodyRead = %v, BodyBytes = %d; want true, %d", result.BodyRead, result.BodyBytes, tt.wantSize)
+			}
+			if tt.wantErr == "" {
+				if !result.IsHealthy || result.Error != nil {
+					t.Errorf("IsHealthy = %v, Error = %v; want healthy", result.IsHealthy, result.Error)
+				}
+				return
+			}
+			if result.IsHealthy {
+				t.Error("IsHealthy = true, want false")
+			}
+			if result.Error == nil || result.Error.Error() != tt.wantErr {
+				t.Errorf("Error = %v, want %q", result.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckSkipsBodyOfFailingStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	result := checkOne(t, &Checker{}, Endpoint{Name: "failing", URL: srv.URL, Timeout: Duration(time.Second), ExpectBody: "ok"})
+	if result.IsHealthy || result.StatusCode != http.StatusInternalServerError {
+		t.Errorf("IsHealthy = %v, StatusCode = %d; want false, 500", result.IsHealthy, result.StatusCode)
+	}
+	if result.BodyRead {
+		t.Errorf("BodyRead = true with BodyBytes = %d, want the body left unread", result.BodyBytes)
+	}
+}
+
+func TestCheckRecoversFromPanic(t *testing.T) {
+	c := &Checker{Concurrency: 2}
+	c.attempt = func(ctx context.Context, endpoint Endpoint) Result {
+		if endpoint.Name == "panics" {
+			panic("something broke")
+		}
+		return Result{Endpoint: endpoint, IsHealthy: true, StatusCode: http.StatusOK}
+	}
+	endpoints := []Endpoint{
+		{Name: "before", URL: "http://before.test"},
+		{Name: "panics", URL: "http://panics.test"},
+		{Name: "after", URL: "http://after.test"},
+	}
+
+	results := c.Check(context.Background(), endpoints)
+	if len(results) != len(endpoints) {
+		t.Fatalf("Check returned %d results, want %d", len(results), len(endpoints))
+	}
+	for _, result := range results {
+		if result.Endpoint.Name == "panics" {
+			if result.IsHealthy {
+				t.Error("panicking endpoint is healthy, want unhealthy")
+			}
+			if result.Error == nil || !strings.Contains(result.Error.Error(), "check panicked") {
+				t.Errorf("panicking endpoint Error = %v, want \"check panicked\"", result.Error)
+			}
+			continue
+		}
+		if !result.IsHealthy {
+			t.Errorf("%s is unhealthy (%v), want healthy", result.Endpoint.Name, result.Error)
+		}
+	}
+}
+
+func TestCheckCutShort(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	ep := Endpoint{Name: "slow", URL: srv.URL, Timeout: Duration(time.Second)}
+
+	// Running out of time, e.g. a run timeout, fails the check
+	errRunOut := errors.New("run out of time")
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 50*time.Millisecond, errRunOut)
+	defer cancel()
+	result := (&Checker{}).Check(ctx, []Endpoint{ep})[0]
+	if result.Cancelled() || result.ErrorKind != ErrorKindTimeout || !errors.Is(result.Error, errRunOut) {
+		t.Errorf("timed out: Cancelled() = %v, ErrorKind = %q, Error = %v; want false, timeout and the cause",
+			result.Cancelled(), result.ErrorKind, result.Error)
+	}
+
+	// Cancelling the context cancels the check
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	result = (&Checker{}).Check(ctx, []Endpoint{ep})[0]
+	if !result.Cancelled() || result.ErrorKind != ErrorKindCancelled {
+		t.Errorf("cancelled: Cancelled() = %v, ErrorKind = %q; want true and cancelled", result.Cancelled(), result.ErrorKind)
+	}
+}
@@ -0,0 +1,130 @@
+package healthcheck
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CheckDependencies reports DependsOn names that match no endpoint, and
+// dependency cycles, which would leave the endpoints in them never checked
+func CheckDependencies(endpoints []Endpoint) error {
+	names := make(map[string]bool, len(endpoints))
+	for _, ep := range endpoints {
+		names[ep.Name] = true
+	}
+
+	var errs []error
+	for _, ep := range endpoints {
+		for _, dep := range ep.DependsOn {
+			if !names[dep] {
+				errs = append(errs, fmt.Errorf("%s depends on unknown endpoint %q", ep.Name, dep))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if cycle := findCycle(endpoints); cycle != nil {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " → "))
+	}
+	return nil
+}
+
+// findCycle returns the names around the first dependency cycle it finds,
+// starting and ending with the same one, or nil if there are none
+func findCycle(endpoints []Endpoint) []string {
+	deps := make(map[string][]string, len(endpoints))
+	for _, ep := range endpoints {
+		deps[ep.Name] = append(deps[ep.Name], ep.DependsOn...)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(deps))
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string(nil), path[i:]...), name)
+				}
+			}
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range deps[name] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, ep := range endpoints {
+		if cycle := visit(ep.Name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// dependencyOrder sorts endpoints so that each comes after everything it
+// depends on, returning their indexes along with the indexes each one
+// waits for. Dependencies that aren't among the endpoints are ignored.
+// Endpoints in or behind a cycle are left out of order and returned as
+// stuck.
+func dependencyOrder(endpoints []Endpoint) (order []int, deps [][]int, stuck []int) {
+	byName := make(map[string][]int, len(endpoints))
+	for i, ep := range endpoints {
+		byName[ep.Name] = append(byName[ep.Name], i)
+	}
+
+	deps = make([][]int, len(endpoints))
+	dependents := make([][]int, len(endpoints))
+	waiting := make([]int, len(endpoints))
+	for i, ep := range endpoints {
+		for _, name := range ep.DependsOn {
+			for _, d := range byName[name] {
+				deps[i] = append(deps[i], d)
+				dependents[d] = append(dependents[d], i)
+				waiting[i]++
+			}
+		}
+	}
+
+	// Endpoints with nothing to wait for keep their original order
+	order = make([]int, 0, len(endpoints))
+	for i := range endpoints {
+		if waiting[i] == 0 {
+			order = append(order, i)
+		}
+	}
+	for next := 0; next < len(order); next++ {
+		for _, d := range dependents[order[next]] {
+			waiting[d]--
+			if waiting[d] == 0 {
+				order = append(order, d)
+			}
+		}
+	}
+
+	for i := range endpoints {
+		if waiting[i] > 0 {
+			stuck = append(stuck, i)
+		}
+	}
+	return order, deps, stuck
+}
@@ -0,0 +1,58 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// checkDNS reports whether the host of a dns://hostname endpoint resolves
+// to addresses of network, which is "ip", "ip4" or "ip6"
+func checkDNS(ctx context.Context, endpoint Endpoint, network string) Result {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return Result{Endpoint: endpoint, Error: err, ErrorKind: ErrorKindInvalid}
+	}
+	if u.Hostname() == "" {
+		return Result{Endpoint: endpoint, Error: fmt.Errorf("dns endpoint %s has no hostname", endpoint.URL), ErrorKind: ErrorKindInvalid}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(endpoint.Timeout))
+	defer cancel()
+
+	start := time.Now()
+	ips, err := net.DefaultResolver.LookupIP(ctx, network, u.Hostname())
+	duration := time.Since(start)
+
+	if err != nil {
+		// Running out of the endpoint's own timeout is a failure, but
+		// anything else that ended ctx cancelled the check
+		if ctx.Err() != nil && !errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+			err = cancelledError(ctx)
+		}
+		return Result{
+			Endpoint:  endpoint,
+			IsHealthy: false,
+			Duration:  duration,
+			Error:     err,
+		}
+	}
+
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, ip.String())
+	}
+	result := Result{
+		Endpoint:  endpoint,
+		IsHealthy: len(addrs) > 0,
+		Duration:  duration,
+		Addresses: addrs,
+	}
+	if !result.IsHealthy {
+		result.ErrorKind = ErrorKindDNS
+	}
+	return result
+}
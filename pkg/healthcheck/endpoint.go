@@ -0,0 +1,172 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Endpoint represents a service to health check
+type Endpoint struct {
+	Name              string            `json:"name" yaml:"name"`
+	URL               string            `json:"url" yaml:"url"`
+	ExpectedStatus    string            `json:"expected_status" yaml:"expected_status"`
+	Method            string            `json:"method" yaml:"method"`
+	Headers           map[string]string `json:"headers" yaml:"headers"`
+	MaxLatency        Duration          `json:"max_latency" yaml:"max_latency"`
+	MaxLatencyFail    Duration          `json:"max_latency_fail" yaml:"max_latency_fail"`
+	ExpectBody        string            `json:"expect_body" yaml:"expect_body"`
+	ExpectBodyRegex   string            `json:"expect_body_regex" yaml:"expect_body_regex"`
+	MinBodyBytes      int64             `json:"min_body_bytes" yaml:"min_body_bytes"`
+	MaxBodyBytes      int64             `json:"max_body_bytes" yaml:"max_body_bytes"`
+	ExpectContentType string            `json:"expect_content_type" yaml:"expect_content_type"`
+	ExpectJSON        []string          `json:"expect_json" yaml:"expect_json"`
+	ExpectHTTP2       bool              `json:"expect_http2" yaml:"expect_http2"`
+	// ExpectHeaders are response headers the endpoint must send, as
+	// "Key: Value", or just "Key" for any value
+	ExpectHeaders []string `json:"expect_headers" yaml:"expect_headers"`
+
+	// Tags group endpoints, e.g. "env:prod" or "team:payments"
+	Tags []string `json:"tags" yaml:"tags"`
+
+	// Weight is how much the endpoint counts towards a weighted health
+	// score, relative to the others; zero means 1
+	Weight int `json:"weight" yaml:"weight"`
+
+	// DependsOn names endpoints that must be healthy for this one to be
+	// checked, e.g. the database behind an app
+	DependsOn []string `json:"depends_on" yaml:"depends_on"`
+
+	// Body is sent with HTTP requests. Without a Content-Type header it is
+	// sent as application/json if it parses as JSON, and text/plain if not.
+	Body string `json:"body" yaml:"body"`
+
+	// UserAgent is sent as the User-Agent header unless Headers sets one;
+	// empty leaves Go's default
+	UserAgent string `json:"user_agent" yaml:"user_agent"`
+
+	// Timeout limits each attempt; zero means DefaultTimeout
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+
+	// Credentials for HTTP basic auth. These are never printed or logged.
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+
+	// invalid is set by SetInvalid
+	invalid error
+
+	// expected and bodyRegex are parsed from the fields above by Compile
+	compiled      bool
+	expected      []statusRange
+	bodyRegex     *regexp.Regexp
+	jsonAsserts   []jsonAssertion
+	headerAsserts []headerAssertion
+}
+
+// SetInvalid marks an endpoint that couldn't be loaded, so that checking
+// it reports err as an unhealthy result without making a request
+func (e *Endpoint) SetInvalid(err error) {
+	e.invalid = err
+}
+
+// Invalid returns the error given to SetInvalid, if any
+func (e Endpoint) Invalid() error {
+	return e.invalid
+}
+
+// Compile parses ExpectedStatus and ExpectBodyRegex, so mistakes in them
+// can be reported before anything is checked. Checker.Check compiles any
+// endpoint that hasn't been already.
+func (e *Endpoint) Compile() error {
+	e.expected, e.bodyRegex, e.jsonAsserts, e.headerAsserts = nil, nil, nil, nil
+
+	if e.ExpectedStatus != "" {
+		expected, err := parseStatusCodes(e.ExpectedStatus)
+		if err != nil {
+			return err
+		}
+		e.expected = expected
+	}
+	if e.ExpectBodyRegex != "" {
+		re, err := regexp.Compile(e.ExpectBodyRegex)
+		if err != nil {
+			return fmt.Errorf("invalid body regex: %w", err)
+		}
+		e.bodyRegex = re
+	}
+	for _, expr := range e.ExpectJSON {
+		a, err := parseJSONAssertion(expr)
+		if err != nil {
+			return err
+		}
+		e.jsonAsserts = append(e.jsonAsserts, a)
+	}
+	for _, expr := range e.ExpectHeaders {
+		a, err := parseHeaderAssertion(expr)
+		if err != nil {
+			return err
+		}
+		e.headerAsserts = append(e.headerAsserts, a)
+	}
+	if e.Weight < 0 {
+		return fmt.Errorf("weight %d must not be negative", e.Weight)
+	}
+	if e.MaxLatencyFail < 0 || e.MaxLatency < 0 {
+		return fmt.Errorf("latency thresholds must not be negative")
+	}
+	if e.MaxBodyBytes > 0 && e.MinBodyBytes > e.MaxBodyBytes {
+		return fmt.Errorf("min body bytes %d is greater than max %d", e.MinBodyBytes, e.MaxBodyBytes)
+	}
+
+	e.compiled = true
+	return nil
+}
+
+// Duration is a time.Duration that can be written as a string like "500ms"
+// in config files, or a bare number of seconds like --timeout takes.
+type Duration time.Duration
+
+// parseDuration reads a Go duration, or a bare number as seconds
+func parseDuration(s string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case float64:
+		*d = Duration(value * float64(time.Second))
+	case string:
+		parsed, err := parseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var raw string
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	parsed, err := parseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
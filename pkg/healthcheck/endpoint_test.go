@@ -0,0 +1,41 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDurationUnmarshal(t *testing.T) {
+	tests := []struct {
+		json, yaml string
+		want       time.Duration
+	}{
+		{`"500ms"`, `500ms`, 500 * time.Millisecond},
+		{`"1m30s"`, `1m30s`, 90 * time.Second},
+		// Bare numbers are seconds, like --timeout 5
+		{`5`, `5`, 5 * time.Second},
+		{`0.5`, `0.5`, 500 * time.Millisecond},
+		{`"2"`, `"2"`, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		var fromJSON, fromYAML Duration
+		if err := json.Unmarshal([]byte(tt.json), &fromJSON); err != nil {
+			t.Errorf("JSON %s: %v", tt.json, err)
+		} else if time.Duration(fromJSON) != tt.want {
+			t.Errorf("JSON %s = %v, want %v", tt.json, time.Duration(fromJSON), tt.want)
+		}
+		if err := yaml.Unmarshal([]byte(tt.yaml), &fromYAML); err != nil {
+			t.Errorf("YAML %s: %v", tt.yaml, err)
+		} else if time.Duration(fromYAML) != tt.want {
+			t.Errorf("YAML %s = %v, want %v", tt.yaml, time.Duration(fromYAML), tt.want)
+		}
+	}
+
+	var d Duration
+	if err := json.Unmarshal([]byte(`"5x"`), &d); err == nil {
+		t.Errorf(`JSON "5x" = %v, want an error`, time.Duration(d))
+	}
+}
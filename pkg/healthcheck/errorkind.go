@@ -0,0 +1,123 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorKind classifies why a check failed, so failures can be told apart
+// without matching on the error text
+type ErrorKind string
+
+const (
+	// ErrorKindNone is the kind of healthy results
+	ErrorKindNone ErrorKind = ""
+	// ErrorKindDNS is a host that couldn't be resolved, or a dns://
+	// endpoint with no addresses
+	ErrorKindDNS ErrorKind = "dns"
+	// ErrorKindTimeout is an attempt that ran out of time, a check cut
+	// short by a run timeout, or a ping that got no reply
+	ErrorKindTimeout ErrorKind = "timeout"
+	// ErrorKindConnRefused is a connection the host refused
+	ErrorKindConnRefused ErrorKind = "conn_refused"
+	// ErrorKindConnection is any other network failure, like a reset or
+	// unreachable host
+	ErrorKindConnection ErrorKind = "connection"
+	// ErrorKindTLS is a failed handshake or certificate verification
+	ErrorKindTLS ErrorKind = "tls"
+	// ErrorKindStatus is a response with a failing status: an HTTP status
+	// code outside expected_status, or a gRPC serving status other than
+	// SERVING
+	ErrorKindStatus ErrorKind = "status"
+	// ErrorKindAssertion is a response that failed a check on its body,
+	// headers, Content-Type or protocol
+	ErrorKindAssertion ErrorKind = "assertion"
+	// ErrorKindLatency is a response slower than MaxLatencyFail
+	ErrorKindLatency ErrorKind = "latency"
+	// ErrorKindInvalid is an endpoint that couldn't be checked as
+	// configured, like a bad URL or a dependency cycle
+	ErrorKindInvalid ErrorKind = "invalid"
+	// ErrorKindSkipped is an endpoint skipped for an unhealthy dependency
+	ErrorKindSkipped ErrorKind = "skipped"
+	// ErrorKindCancelled is a check aborted before it could finish
+	ErrorKindCancelled ErrorKind = "cancelled"
+	// ErrorKindUnknown is a failure that fits none of the other kinds
+	ErrorKindUnknown ErrorKind = "unknown"
+)
+
+// classify gives the kind of a result's failure. Checks set the kinds
+// they know from where the failure happened, and the rest come from the
+// error itself.
+func classify(r Result) ErrorKind {
+	switch {
+	case r.IsHealthy:
+		return ErrorKindNone
+	// These win, since either can cut short a retry after any failure
+	case r.Cancelled():
+		return ErrorKindCancelled
+	case errors.Is(r.Error, errCheckTimedOut):
+		return ErrorKindTimeout
+	case r.Skipped:
+		return ErrorKindSkipped
+	case r.ErrorKind != ErrorKindNone:
+		return r.ErrorKind
+	}
+	return classifyError(r.Error)
+}
+
+// classifyError gives the kind of a network or protocol error
+func classifyError(err error) ErrorKind {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var opErr *net.OpError
+	switch {
+	case err == nil:
+		return ErrorKindUnknown
+	// The same rule as Result.Cancelled
+	case errors.Is(err, context.Canceled):
+		return ErrorKindCancelled
+	// Checked before timeouts, since a lookup that times out is a DNS error
+	case errors.As(err, &dnsErr):
+		return ErrorKindDNS
+	case errors.Is(err, errCheckTimedOut) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorKindTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorKindConnRefused
+	case isTLSError(err):
+		return ErrorKindTLS
+	case errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET):
+		return ErrorKindConnection
+	}
+
+	// gRPC errors carry a code rather than wrapping the network error
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.DeadlineExceeded:
+			return ErrorKindTimeout
+		case codes.Unavailable:
+			return ErrorKindConnection
+		}
+	}
+	return ErrorKindUnknown
+}
+
+// isTLSError reports whether err came from the TLS handshake or verifying
+// the server's certificate
+func isTLSError(err error) bool {
+	var verifyErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &verifyErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
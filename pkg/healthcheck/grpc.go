@@ -0,0 +1,81 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+// checkGRPC calls grpc.health.v1.Health/Check on a grpc://host:port endpoint.
+// A path, as in grpc://host:port/my.Service, asks about that service rather
+// than the server as a whole. Connections are dialed over network, which is
+// "tcp", "tcp4" or "tcp6".
+func checkGRPC(ctx context.Context, endpoint Endpoint, network string) Result {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return Result{Endpoint: endpoint, Error: err, ErrorKind: ErrorKindInvalid}
+	}
+	if u.Port() == "" {
+		return Result{Endpoint: endpoint, Error: fmt.Errorf("grpc endpoint %s has no port", endpoint.URL), ErrorKind: ErrorKindInvalid}
+	}
+	service := strings.TrimPrefix(u.Path, "/")
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(endpoint.Timeout))
+	defer cancel()
+
+	// NewClient connects lazily, so dialing counts towards the check's duration
+	// The resolver hands over each address it finds, and ones of the wrong
+	// family fail to dial, so only those of the right one are tried
+	dial := func(ctx context.Context, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithContextDialer(dial))
+	if err != nil {
+		return Result{Endpoint: endpoint, Error: fmt.Errorf("failed to create grpc client: %w", err), ErrorKind: ErrorKindInvalid}
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	var remote peer.Peer
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service}, grpc.Peer(&remote))
+	duration := time.Since(start)
+
+	if err != nil {
+		// Running out of the endpoint's own timeout is a failure, but
+		// anything else that ended ctx cancelled the check
+		if ctx.Err() != nil && !errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+			err = cancelledError(ctx)
+		}
+		return Result{
+			Endpoint:  endpoint,
+			IsHealthy: false,
+			Duration:  duration,
+			Error:     err,
+		}
+	}
+
+	status := resp.GetStatus()
+	result := Result{
+		Endpoint:      endpoint,
+		IsHealthy:     status == healthpb.HealthCheckResponse_SERVING,
+		Duration:      duration,
+		ServingStatus: status.String(),
+	}
+	if remote.Addr != nil {
+		result.RemoteAddr = remote.Addr.String()
+	}
+	if !result.IsHealthy {
+		result.Error = fmt.Errorf("serving status %s", status)
+		result.ErrorKind = ErrorKindStatus
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonAssertion checks that the value at a path in a JSON body equals an
+// expected value, as in "status=ok" or "$.checks[0].up=true"
+type jsonAssertion struct {
+	path    []string
+	rawWant string
+	// want is the expected value decoded as JSON, or the literal text when
+	// it isn't valid JSON, so both status=ok and status="ok" work
+	want interface{}
+}
+
+// parseJSONAssertion parses a path=value expression. Paths are dot
+// separated keys with optional [n] array indexes and an optional $. prefix.
+func parseJSONAssertion(expr string) (jsonAssertion, error) {
+	rawPath, rawWant, ok := strings.Cut(expr, "=")
+	rawPath = strings.TrimSpace(rawPath)
+	if !ok || rawPath == "" {
+		return jsonAssertion{}, fmt.Errorf("invalid JSON assertion %q: expected path=value", expr)
+	}
+
+	rawPath = strings.TrimPrefix(strings.TrimPrefix(rawPath, "$"), ".")
+	var path []string
+	for _, part := range strings.Split(rawPath, ".") {
+		// Split "items[0][1]" into "items", "0", "1"
+		key, rest, _ := strings.Cut(part, "[")
+		if key != "" {
+			path = append(path, key)
+		}
+		for rest != "" {
+			index, after, ok := strings.Cut(rest, "]")
+			if _, err := strconv.Atoi(index); !ok || err != nil {
+				return jsonAssertion{}, fmt.Errorf("invalid JSON assertion %q: bad array index in %q", expr, part)
+			}
+			path = append(path, index)
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	if len(path) == 0 {
+		return jsonAssertion{}, fmt.Errorf("invalid JSON assertion %q: empty path", expr)
+	}
+
+	a := jsonAssertion{path: path, rawWant: rawWant}
+	if err := json.Unmarshal([]byte(rawWant), &a.want); err != nil {
+		a.want = rawWant
+	}
+	return a, nil
+}
+
+// check looks up the path in a decoded body and compares it with want
+func (a jsonAssertion) check(doc interface{}) error {
+	value := doc
+	for i, key := range a.path {
+		at := strings.Join(a.path[:i+1], ".")
+		switch node := value.(type) {
+		case map[string]interface{}:
+			v, ok := node[key]
+			if !ok {
+				return fmt.Errorf("JSON path %s not found", at)
+			}
+			value = v
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return fmt.Errorf("JSON path %s not found", at)
+			}
+			value = node[index]
+		default:
+			return fmt.Errorf("JSON path %s not found", at)
+		}
+	}
+
+	if !reflect.DeepEqual(value, a.want) {
+		got, _ := json.Marshal(value)
+		return fmt.Errorf("JSON path %s is %s, expected %s", strings.Join(a.path, "."), got, a.rawWant)
+	}
+	return nil
+}
+
+// checkJSON decodes body and runs every assertion against it
+func checkJSON(assertions []jsonAssertion, body []byte) error {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("body is not valid JSON: %w", err)
+	}
+	for _, a := range assertions {
+		if err := a.check(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// pingCount is how many echo requests each ping:// check sends
+const pingCount = 3
+
+// pingSocket describes how to talk ICMP for one address family
+type pingSocket struct {
+	// unprivileged uses a datagram ping socket, which Linux and macOS allow
+	// without root; raw needs CAP_NET_RAW or root
+	unprivileged, raw string
+	listen            string
+	proto             int
+	echo, reply       icmp.Type
+}
+
+var (
+	ping4 = pingSocket{"udp4", "ip4:icmp", "0.0.0.0", 1, ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply}
+	ping6 = pingSocket{"udp6", "ip6:ipv6-icmp", "::", 58, ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply}
+)
+
+// checkPing sends ICMP echo requests to a ping://host endpoint. It is healthy
+// if any reply comes back; the duration is the average round trip.
+func (c *Checker) checkPing(ctx context.Context, endpoint Endpoint) Result {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return Result{Endpoint: endpoint, Error: err, ErrorKind: ErrorKindInvalid}
+	}
+	if u.Hostname() == "" {
+		return Result{Endpoint: endpoint, Error: fmt.Errorf("ping endpoint %s has no host", endpoint.URL), ErrorKind: ErrorKindInvalid}
+	}
+
+	ip, err := resolvePingTarget(ctx, u.Hostname(), c.ipNetwork())
+	if err != nil {
+		if ctx.Err() != nil {
+			err = cancelledError(ctx)
+		}
+		return Result{Endpoint: endpoint, Error: err, ErrorKind: ErrorKindDNS}
+	}
+
+	sock := ping4
+	if ip.To4() == nil {
+		sock = ping6
+	}
+	conn, unprivileged, err := listenPing(sock)
+	if err != nil {
+		return Result{Endpoint: endpoint, Error: err}
+	}
+	defer conn.Close()
+
+	// Datagram sockets are addressed by UDP, and the kernel picks the echo ID
+	var dst net.Addr = &net.IPAddr{IP: ip}
+	if unprivileged {
+		dst = &net.UDPAddr{IP: ip}
+	}
+
+	// Split the timeout between the echoes so the whole check respects it
+	wait := time.Duration(endpoint.Timeout) / pingCount
+	id := os.Getpid() & 0xffff
+
+	var received int
+	var total time.Duration
+	for seq := 1; seq <= pingCount; seq++ {
+		if ctx.Err() != nil {
+			return Result{Endpoint: endpoint, Error: cancelledError(ctx)}
+		}
+
+		rtt, err := pingOnce(conn, sock, dst, id, seq, wait)
+		if err != nil {
+			c.logger().Debug("ping failed", "name", endpoint.Name, "seq", seq, "error", err)
+			continue
+		}
+		received++
+		total += rtt
+	}
+
+	result := Result{
+		Endpoint:    endpoint,
+		IsHealthy:   received > 0,
+		PacketsSent: pingCount,
+		PacketsLost: pingCount - received,
+	}
+	if received == 0 {
+		result.Error = fmt.Errorf("no reply from %s: %d of %d packets lost", ip, pingCount, pingCount)
+		result.ErrorKind = ErrorKindTimeout
+		return result
+	}
+	result.Duration = total / time.Duration(received)
+	return result
+}
+
+// resolvePingTarget looks up an address of network, which is "ip", "ip4"
+// or "ip6", for host
+func resolvePingTarget(ctx context.Context, host, network string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if network == "ip4" && ip.To4() == nil {
+			return nil, fmt.Errorf("%s is not an IPv4 address", host)
+		}
+		if network == "ip6" && ip.To4() != nil {
+			return nil, fmt.Errorf("%s is not an IPv6 address", host)
+		}
+		return ip, nil
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, network, host)
+	if err != nil {
+		return nil, err
+	}
+	// Prefer IPv4, which is what ping does by default
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip, nil
+		}
+	}
+	return ips[0], nil
+}
+
+// listenPing opens an unprivileged ping socket if the OS allows it, and
+// falls back to a raw socket otherwise
+func listenPing(sock pingSocket) (*icmp.PacketConn, bool, error) {
+	conn, err := icmp.ListenPacket(sock.unprivileged, sock.listen)
+	if err == nil {
+		return conn, true, nil
+	}
+	conn, rawErr := icmp.ListenPacket(sock.raw, sock.listen)
+	if rawErr == nil {
+		return conn, false, nil
+	}
+
+	if errors.Is(err, os.ErrPermission) || errors.Is(rawErr, os.ErrPermission) {
+		return nil, false, fmt.Errorf("ping needs permission to open ICMP sockets: run as root, grant CAP_NET_RAW, or allow unprivileged ping via net.ipv4.ping_group_range: %w", rawErr)
+	}
+	return nil, false, fmt.Errorf("failed to open ICMP socket: %w", rawErr)
+}
+
+// pingOnce sends one echo request and waits for its reply
+func pingOnce(conn *icmp.PacketConn, sock pingSocket, dst net.Addr, id, seq int, wait time.Duration) (time.Duration, error) {
+	msg := icmp.Message{
+		Type: sock.echo,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("healthcheck")},
+	}
+	packet, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if err := conn.SetReadDeadline(start.Add(wait)); err != nil {
+		return 0, err
+	}
+	if _, err := conn.WriteTo(packet, dst); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		reply, err := icmp.ParseMessage(sock.proto, buf[:n])
+		if err != nil || reply.Type != sock.reply {
+			continue
+		}
+		// Raw sockets see every echo reply on the host, so match it to ours.
+		// The ID is the same for every check in the process, so a reply from
+		// another host pinged at the same time only differs by where it's from.
+		// Datagram sockets rewrite the ID, but only deliver our own replies.
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok || echo.Seq != seq || !addrIP(peer).Equal(addrIP(dst)) {
+			continue
+		}
+		if _, raw := dst.(*net.IPAddr); raw && echo.ID != id {
+			continue
+		}
+		return time.Since(start), nil
+	}
+}
+
+// addrIP is the IP of a raw or datagram socket address
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	}
+	return nil
+}
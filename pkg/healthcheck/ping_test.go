@@ -0,0 +1,43 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPingIgnoresRepliesFromOtherTargets(t *testing.T) {
+	c := &Checker{}
+	live := Endpoint{Name: "live", URL: "ping://127.0.0.1", Timeout: Duration(time.Second)}
+	if result := checkOne(t, c, live); !result.IsHealthy {
+		t.Skipf("can't ping here: %v", result.Error)
+	}
+	// Nothing answers for this address on most networks
+	dead := Endpoint{Name: "dead", URL: "ping://10.255.255.1", Timeout: Duration(300 * time.Millisecond)}
+	if result := checkOne(t, c, dead); result.IsHealthy {
+		t.Skip("10.255.255.1 answers pings here")
+	}
+
+	// Keep the live target answering the whole time the dead one is checked.
+	// Raw sockets see both targets' replies, with the same echo ID and seqs.
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for ctx.Err() == nil {
+			c.Check(ctx, []Endpoint{live})
+		}
+	}()
+	result := checkOne(t, c, dead)
+	cancel()
+	wg.Wait()
+
+	if result.IsHealthy {
+		t.Errorf("dead target is healthy with %d of %d replies, want unhealthy", result.PacketsSent-result.PacketsLost, result.PacketsSent)
+	}
+	if result.PacketsLost != pingCount {
+		t.Errorf("PacketsLost = %d, want %d", result.PacketsLost, pingCount)
+	}
+}
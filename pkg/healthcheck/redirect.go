@@ -0,0 +1,64 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// DefaultMaxRedirects applies when Checker.MaxRedirects is zero, and
+// matches what browsers allow
+const DefaultMaxRedirects = 10
+
+// Redirect is one hop of a followed redirect chain
+type Redirect struct {
+	URL        string
+	StatusCode int
+}
+
+// redirectChain collects the hops of one request. The client is shared, so
+// the chain travels in the request's context rather than on the client.
+type redirectChain struct {
+	mu   sync.Mutex
+	hops []Redirect
+}
+
+type redirectChainKey struct{}
+
+func withRedirectChain(ctx context.Context) (context.Context, *redirectChain) {
+	chain := &redirectChain{}
+	return context.WithValue(ctx, redirectChainKey{}, chain), chain
+}
+
+func (r *redirectChain) Hops() []Redirect {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hops
+}
+
+// checkRedirect is the shared client's redirect policy
+func (c *Checker) checkRedirect(req *http.Request, via []*http.Request) error {
+	// Stop at the redirect itself so its status code is what gets checked
+	if c.NoRedirects {
+		return http.ErrUseLastResponse
+	}
+
+	// req.Response is the redirect that led here, from the last URL in via
+	from := via[len(via)-1].URL.String()
+	if chain, ok := req.Context().Value(redirectChainKey{}).(*redirectChain); ok && req.Response != nil {
+		chain.mu.Lock()
+		chain.hops = append(chain.hops, Redirect{URL: from, StatusCode: req.Response.StatusCode})
+		chain.mu.Unlock()
+	}
+
+	limit := c.MaxRedirects
+	if limit <= 0 {
+		limit = DefaultMaxRedirects
+	}
+	if len(via) > limit {
+		return fmt.Errorf("stopped after %d redirects", limit)
+	}
+	c.logger().Debug("following redirect", "from", from, "to", req.URL.String())
+	return nil
+}
@@ -0,0 +1,82 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+)
+
+// Result contains detailed results from a health check
+type Result struct {
+	Endpoint   Endpoint
+	IsHealthy  bool
+	StatusCode int
+	Duration   time.Duration
+	Error      error
+	// ErrorKind classifies Error, and is ErrorKindNone for healthy results
+	ErrorKind ErrorKind
+	Attempts  int
+	// Degraded is set when the endpoint is healthy but slower than its
+	// MaxLatency. Slower than MaxLatencyFail makes it unhealthy instead.
+	Degraded bool
+	// Addresses holds the resolved addresses for dns:// endpoints
+	Addresses []string
+	// CertExpiry and CertDaysLeft describe the leaf certificate of HTTPS
+	// endpoints and are left zero when there was no TLS
+	CertExpiry   time.Time
+	CertDaysLeft int
+	// FinalURL is where the request ended up after following redirects
+	FinalURL string
+	// Redirects are the hops followed on the way to FinalURL, in order
+	Redirects []Redirect
+	// BodyBytes is the size of the body, when an assertion needed to read it
+	BodyBytes int64
+	// BodyRead is set when the body was read, which it isn't when the
+	// status or headers already failed
+	BodyRead bool
+	// ServingStatus is the status reported by a grpc:// health check
+	ServingStatus string
+	// PacketsSent and PacketsLost count the echoes of a ping:// check
+	PacketsSent int
+	PacketsLost int
+	// Timings break down the response time of HTTP checks
+	Timings Timings
+	// RemoteAddr is the address the last attempt connected to, e.g.
+	// "93.184.216.34:443", for HTTP, TCP and gRPC checks that connected.
+	// Through a proxy, it's the proxy's address.
+	RemoteAddr string
+	// Proto is the protocol the HTTP response came over, e.g. "HTTP/2.0"
+	Proto string
+	// RequestID is the ID sent with the last attempt, when the Checker has
+	// a RequestIDHeader
+	RequestID string
+	// Skipped is set when the endpoint wasn't checked because something it
+	// DependsOn wasn't healthy; Error says which
+	Skipped bool
+}
+
+// Responded reports whether the endpoint answered at all, even if a later
+// assertion failed. A TCP check has no status code but answers by connecting.
+func (r Result) Responded() bool {
+	return r.StatusCode != 0 || r.ServingStatus != "" || r.Error == nil
+}
+
+// AddressFamily is "IPv4" or "IPv6" for the RemoteAddr, or empty when
+// there isn't one
+func (r Result) AddressFamily() string {
+	addr, err := netip.ParseAddrPort(r.RemoteAddr)
+	switch {
+	case err != nil:
+		return ""
+	case addr.Addr().Unmap().Is4():
+		return "IPv4"
+	default:
+		return "IPv6"
+	}
+}
+
+// Cancelled reports whether the check was aborted before it could finish
+func (r Result) Cancelled() bool {
+	return errors.Is(r.Error, context.Canceled)
+}
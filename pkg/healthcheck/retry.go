@@ -0,0 +1,94 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// DefaultRetryOn is the RetryPolicy used when Checker.RetryOn is nil:
+// failures that got no response at all, and server errors
+const DefaultRetryOn = "connection,500-599"
+
+// RetryPolicy decides which failed attempts are worth retrying, so that a
+// definitive answer like a 404 fails fast instead of being retried
+type RetryPolicy struct {
+	codes []statusRange
+	// Error classes, see ParseRetryPolicy
+	timeout     bool
+	connRefused bool
+	connection  bool
+	any         bool
+}
+
+// ParseRetryPolicy parses a comma-separated list of status codes or ranges,
+// like "502,503" or "500-599", and error classes:
+//
+//	timeout      the attempt timed out
+//	connrefused  the connection was refused
+//	connection   no response at all, including timeouts, refused
+//	             connections and DNS failures
+//	any          every failure, including failed assertions
+func ParseRetryPolicy(spec string) (*RetryPolicy, error) {
+	p := &RetryPolicy{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		switch strings.ToLower(part) {
+		case "":
+			continue
+		case "timeout":
+			p.timeout = true
+		case "connrefused":
+			p.connRefused = true
+		case "connection":
+			p.connection = true
+		case "any":
+			p.any = true
+		default:
+			codes, err := parseStatusCodes(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid retry condition %q: expected a status code, range, or one of timeout, connrefused, connection, any", part)
+			}
+			p.codes = append(p.codes, codes...)
+		}
+	}
+	return p, nil
+}
+
+// defaultRetryPolicy is parsed once from DefaultRetryOn, which is known good
+var defaultRetryPolicy, _ = ParseRetryPolicy(DefaultRetryOn)
+
+// shouldRetry reports whether a failed result matches the policy, and why
+func (p *RetryPolicy) shouldRetry(r Result) (bool, string) {
+	if p.any {
+		return true, "any failure"
+	}
+
+	if r.StatusCode != 0 {
+		for _, codes := range p.codes {
+			if r.StatusCode >= codes.Min && r.StatusCode <= codes.Max {
+				return true, "status " + strconv.Itoa(r.StatusCode)
+			}
+		}
+		return false, ""
+	}
+
+	// Anything below is about attempts that got no response
+	if r.Error == nil || r.ServingStatus != "" {
+		return false, ""
+	}
+	var netErr net.Error
+	switch {
+	case p.timeout && (errors.Is(r.Error, context.DeadlineExceeded) || errors.As(r.Error, &netErr) && netErr.Timeout()):
+		return true, "timeout"
+	case p.connRefused && errors.Is(r.Error, syscall.ECONNREFUSED):
+		return true, "connection refused"
+	case p.connection:
+		return true, "no response"
+	}
+	return false, ""
+}
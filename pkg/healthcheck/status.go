@@ -0,0 +1,73 @@
+package healthcheck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// statusRange is an inclusive range of acceptable HTTP status codes
+type statusRange struct {
+	Min int
+	Max int
+}
+
+// parseStatusCodes parses a list like "200,204,301-302" into status ranges
+func parseStatusCodes(spec string) ([]statusRange, error) {
+	var ranges []statusRange
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		lo, hi, isRange := strings.Cut(part, "-")
+		min, err := parseStatusCode(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status %q: %w", part, err)
+		}
+		max := min
+		if isRange {
+			max, err = parseStatusCode(hi)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status range %q: %w", part, err)
+			}
+			if max < min {
+				return nil, fmt.Errorf("invalid status range %q: end is before start", part)
+			}
+		}
+
+		ranges = append(ranges, statusRange{Min: min, Max: max})
+	}
+
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no status codes in %q", spec)
+	}
+	return ranges, nil
+}
+
+func parseStatusCode(s string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	if code < 100 || code > 599 {
+		return 0, fmt.Errorf("%d is not a valid HTTP status code", code)
+	}
+	return code, nil
+}
+
+// statusHealthy reports whether code is acceptable. Without explicit
+// ranges anything from 200 to 399 counts as healthy.
+func statusHealthy(ranges []statusRange, code int) bool {
+	if len(ranges) == 0 {
+		return code >= 200 && code < 400
+	}
+	for _, r := range ranges {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// checkTCP reports whether a tcp://host:port endpoint accepts connections
+// over network, which is "tcp", "tcp4" or "tcp6"
+func checkTCP(ctx context.Context, endpoint Endpoint, network string) Result {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return Result{Endpoint: endpoint, Error: err, ErrorKind: ErrorKindInvalid}
+	}
+	if u.Port() == "" {
+		return Result{Endpoint: endpoint, Error: fmt.Errorf("tcp endpoint %s has no port", endpoint.URL), ErrorKind: ErrorKindInvalid}
+	}
+
+	dialer := &net.Dialer{Timeout: time.Duration(endpoint.Timeout)}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, network, u.Host)
+	duration := time.Since(start)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			err = cancelledError(ctx)
+		}
+		return Result{
+			Endpoint:  endpoint,
+			IsHealthy: false,
+			Duration:  duration,
+			Error:     err,
+		}
+	}
+	conn.Close()
+
+	return Result{
+		Endpoint:   endpoint,
+		IsHealthy:  true,
+		Duration:   duration,
+		RemoteAddr: conn.RemoteAddr().String(),
+	}
+}
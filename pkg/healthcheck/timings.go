@@ -0,0 +1,99 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings break an HTTP check's response time down by phase. A phase that
+// didn't happen, like DNS for an IP address or TLS for plain HTTP, or a
+// connection reused from an earlier attempt, is left zero. Phases are
+// summed over redirects.
+type Timings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// FirstByte is from the start of the request to the first byte of the
+	// final response, so it includes the phases above
+	FirstByte time.Duration
+}
+
+// timingTrace records Timings, and the address of the connection the
+// request went over, from httptrace hooks, which may run on other
+// goroutines, e.g. when dialing several addresses at once
+type timingTrace struct {
+	mu       sync.Mutex
+	start    time.Time
+	dnsStart time.Time
+	tlsStart time.Time
+	dials    map[string]time.Time
+	timings  Timings
+	remote   string
+}
+
+// withTimingTrace returns a context that records into the returned trace
+func withTimingTrace(ctx context.Context) (context.Context, *timingTrace) {
+	t := &timingTrace{start: time.Now(), dials: make(map[string]time.Time)}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.DNS += time.Since(t.dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dials[network+addr] = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// Only the dial that won counts when several race
+			if err == nil {
+				t.timings.Connect += time.Since(t.dials[network+addr])
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.TLS += time.Since(t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.remote = info.Conn.RemoteAddr().String()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.FirstByte = time.Since(t.start)
+		},
+	}), t
+}
+
+// RemoteAddr returns the address of the last connection used, if any
+func (t *timingTrace) RemoteAddr() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remote
+}
+
+// Timings returns what has been recorded so far
+func (t *timingTrace) Timings() Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.timings
+}